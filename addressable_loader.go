@@ -0,0 +1,224 @@
+package sdk
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/graph-gophers/dataloader/v7"
+	"github.com/nbd-wtf/go-nostr"
+)
+
+// FetchAddressableEvent fetches the latest version of a parameterized replaceable event (kinds 30000-39999)
+// from the local store or, failing that, from the author's outbox relays, using a batched loader.
+// the event found in relays is saved in the local store.
+func (sys *System) FetchAddressableEvent(ctx context.Context, pointer nostr.EntityPointer) (*nostr.Event, error) {
+	if pointer.Kind < 30000 || pointer.Kind >= 40000 {
+		return nil, fmt.Errorf("kind %d is not addressable", pointer.Kind)
+	}
+	if !nostr.IsValidPublicKey(pointer.PublicKey) {
+		return nil, fmt.Errorf("invalid public key '%s'", pointer.PublicKey)
+	}
+
+	res, _ := sys.StoreRelay.QuerySync(ctx, nostr.Filter{
+		Kinds:   []int{pointer.Kind},
+		Authors: []string{pointer.PublicKey},
+		Tags:    nostr.TagMap{"d": []string{pointer.Identifier}},
+	})
+	if len(res) != 0 {
+		return res[0], nil
+	}
+
+	thunk := sys.addressableLoader.Load(ctx, addressableKey(pointer.Kind, pointer.PublicKey, pointer.Identifier))
+	evt, err := thunk()
+	if err != nil {
+		return nil, err
+	}
+
+	sys.StoreRelay.Publish(ctx, *evt)
+	return evt, nil
+}
+
+func addressableKey(kind int, pubkey string, d string) string {
+	return strconv.Itoa(kind) + ":" + pubkey + ":" + d
+}
+
+func (sys *System) createAddressableDataloader() *dataloader.Loader[string, *nostr.Event] {
+	return dataloader.NewBatchedLoader(
+		func(
+			ctx context.Context,
+			addresses []string,
+		) []*dataloader.Result[*nostr.Event] {
+			return sys.batchLoadAddressableEvents(ctx, addresses)
+		},
+		dataloader.WithBatchCapacity[string, *nostr.Event](60),
+		dataloader.WithClearCacheOnBatch[string, *nostr.Event](),
+		dataloader.WithWait[string, *nostr.Event](time.Millisecond*350),
+	)
+}
+
+// batchLoadAddressableEvents takes a list of "<kind>:<pubkey>:<d-tag>" addresses and queries each author's
+// relays for them, grouping the queries so each relay gets a single filter per kind.
+func (sys *System) batchLoadAddressableEvents(
+	ctx context.Context,
+	addresses []string,
+) []*dataloader.Result[*nostr.Event] {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*4)
+	defer cancel()
+
+	batchSize := len(addresses)
+	results := make([]*dataloader.Result[*nostr.Event], batchSize)
+	keyPositions := make(map[string]int)           // { [address]: slice_index }
+	relayFilters := make(map[string]nostr.Filters) // { [relayUrl]: filters }
+	relayAddresses := make(map[string][]string)    // { [relayUrl]: addresses we're asking for }
+
+	wg := sync.WaitGroup{}
+	wg.Add(len(addresses))
+	cm := sync.Mutex{}
+
+	for i, address := range addresses {
+		// build batched queries for the external relays
+		keyPositions[address] = i // this is to help us know where to save the result later
+
+		go func(i int, address string) {
+			defer wg.Done()
+
+			spl := strings.SplitN(address, ":", 3)
+			if len(spl) != 3 {
+				results[i] = &dataloader.Result[*nostr.Event]{
+					Error: fmt.Errorf("invalid address '%s'", address),
+				}
+				return
+			}
+			kind, err := strconv.Atoi(spl[0])
+			if err != nil || kind < 30000 || kind >= 40000 {
+				results[i] = &dataloader.Result[*nostr.Event]{
+					Error: fmt.Errorf("invalid kind in address '%s'", address),
+				}
+				return
+			}
+			pubkey := spl[1]
+			d := spl[2]
+
+			// if we're attempting this query with a short key (last 8 characters), stop here
+			if len(pubkey) != 64 {
+				results[i] = &dataloader.Result[*nostr.Event]{
+					Error: fmt.Errorf("won't proceed to query relays with a shortened key (%d)", kind),
+				}
+				return
+			}
+
+			// save attempts here so we don't try the same failed query over and over
+			if doItNow := DoThisNotMoreThanOnceAnHour("addr:" + address); !doItNow {
+				results[i] = &dataloader.Result[*nostr.Event]{
+					Error: fmt.Errorf("last attempt failed, waiting more to try again"),
+				}
+				return
+			}
+
+			// gather relays we'll use for this pubkey
+			relays := sys.determineRelaysToQuery(ctx, pubkey, kind)
+
+			// by default we will return an error (this will be overwritten when we find an event)
+			results[i] = &dataloader.Result[*nostr.Event]{
+				Error: fmt.Errorf("couldn't find a kind %d event with d-tag '%s' anywhere %v", kind, d, relays),
+			}
+
+			cm.Lock()
+			for _, relay := range relays {
+				// each relay will have a custom filter for each kind
+				filters := relayFilters[relay]
+				idx := slices.IndexFunc(filters, func(f nostr.Filter) bool { return f.Kinds[0] == kind })
+				if idx == -1 {
+					idx = len(filters)
+					filters = append(filters, nostr.Filter{
+						Kinds:   []int{kind},
+						Authors: make([]string, 0, batchSize-i /* this and all addresses after this can be added */),
+						Tags:    nostr.TagMap{"d": make([]string, 0, batchSize-i)},
+					})
+				}
+				if !slices.Contains(filters[idx].Authors, pubkey) {
+					filters[idx].Authors = append(filters[idx].Authors, pubkey)
+				}
+				if !slices.Contains(filters[idx].Tags["d"], d) {
+					filters[idx].Tags["d"] = append(filters[idx].Tags["d"], d)
+				}
+				relayFilters[relay] = filters
+				relayAddresses[relay] = append(relayAddresses[relay], address)
+			}
+			cm.Unlock()
+		}(i, address)
+	}
+
+	// query all relays with the prepared filters
+	wg.Wait()
+	multiSubs := sys.batchAddressableRelayQueries(ctx, relayFilters, relayAddresses)
+	for {
+		select {
+		case evt, more := <-multiSubs:
+			if !more {
+				return results
+			}
+
+			// since we're combining authors and d-tags in the same filter we may get events we didn't ask for
+			pos, ok := keyPositions[addressableKey(evt.Kind, evt.PubKey, evt.Tags.GetD())]
+			if !ok {
+				continue
+			}
+
+			// insert this event at the desired position
+			if results[pos].Data == nil || results[pos].Data.CreatedAt < evt.CreatedAt {
+				results[pos] = &dataloader.Result[*nostr.Event]{Data: evt}
+			}
+		case <-ctx.Done():
+			return results
+		}
+	}
+}
+
+// batchAddressableRelayQueries is like batchReplaceableRelayQueries, but it takes the addresses expected from
+// each relay explicitly since these can't be inferred from the filters (which may match more events than we want).
+func (sys *System) batchAddressableRelayQueries(
+	ctx context.Context,
+	relayFilters map[string]nostr.Filters,
+	relayAddresses map[string][]string,
+) <-chan *nostr.Event {
+	all := make(chan *nostr.Event)
+
+	wg := sync.WaitGroup{}
+	wg.Add(len(relayFilters))
+	for url, filters := range relayFilters {
+		go func(url string, filters nostr.Filters) {
+			defer wg.Done()
+			addresses := relayAddresses[url]
+			n := len(addresses)
+
+			ctx, cancel := context.WithTimeout(ctx, time.Millisecond*450+time.Millisecond*50*time.Duration(n))
+			defer cancel()
+
+			received := 0
+			for ie := range sys.Pool.SubManyEose(ctx, []string{url}, filters) {
+				all <- ie.Event
+				if !slices.Contains(addresses, addressableKey(ie.Kind, ie.PubKey, ie.Tags.GetD())) {
+					continue
+				}
+				received++
+				if received >= n {
+					// we got all events we asked for, unless the relay is shitty and sent us two from the same
+					return
+				}
+			}
+		}(url, filters)
+	}
+
+	go func() {
+		wg.Wait()
+		close(all)
+	}()
+
+	return all
+}
@@ -0,0 +1,78 @@
+package cache_badger
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+	"time"
+
+	"github.com/dgraph-io/badger/v4"
+	"github.com/nbd-wtf/nostr-sdk/cache"
+)
+
+var _ cache.Cache32[any] = (*BadgerCache[any])(nil)
+
+// BadgerCache is a Cache32 that persists its values on disk so they survive restarts.
+//
+// values are encoded with encoding/gob, which means fields tagged with `json:"-"` (like the
+// embedded *nostr.Event in lists and profiles) are kept too. expiration set with SetWithTTL
+// is handled by badger itself.
+type BadgerCache[V any] struct {
+	DB *badger.DB
+}
+
+func New32[V any](path string) (*BadgerCache[V], error) {
+	opts := badger.DefaultOptions(path)
+	opts.Logger = nil
+	db, err := badger.Open(opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open badger at %s: %w", path, err)
+	}
+	return &BadgerCache[V]{DB: db}, nil
+}
+
+func (s BadgerCache[V]) Close() { s.DB.Close() }
+
+func (s BadgerCache[V]) Get(k string) (v V, ok bool) {
+	err := s.DB.View(func(txn *badger.Txn) error {
+		item, err := txn.Get([]byte(k))
+		if err != nil {
+			return err
+		}
+		return item.Value(func(val []byte) error {
+			return gob.NewDecoder(bytes.NewReader(val)).Decode(&v)
+		})
+	})
+	if err != nil {
+		var zero V
+		return zero, false
+	}
+	return v, true
+}
+
+func (s BadgerCache[V]) Delete(k string) {
+	s.DB.Update(func(txn *badger.Txn) error {
+		return txn.Delete([]byte(k))
+	})
+}
+
+func (s BadgerCache[V]) Set(k string, v V) bool {
+	return s.SetWithTTL(k, v, 0)
+}
+
+func (s BadgerCache[V]) SetWithTTL(k string, v V, d time.Duration) bool {
+	buf := bytes.Buffer{}
+	if err := gob.NewEncoder(&buf).Encode(v); err != nil {
+		return false
+	}
+
+	entry := badger.NewEntry([]byte(k), buf.Bytes())
+	if d > 0 {
+		entry = entry.WithTTL(d)
+	}
+
+	err := s.DB.Update(func(txn *badger.Txn) error {
+		return txn.SetEntry(entry)
+	})
+	return err == nil
+}
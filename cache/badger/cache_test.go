@@ -0,0 +1,59 @@
+package cache_badger
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nbd-wtf/go-nostr"
+	"github.com/stretchr/testify/require"
+)
+
+type testList struct {
+	PubKey string       `json:"-"`
+	Event  *nostr.Event `json:"-"`
+
+	Items []string
+}
+
+func TestPersistence(t *testing.T) {
+	path := t.TempDir()
+	c, err := New32[testList](path)
+	require.NoError(t, err)
+
+	const pk = "0000000000000000000000000000000000000000000000000000000000000001"
+	v := testList{
+		PubKey: pk,
+		Event: &nostr.Event{
+			PubKey:    pk,
+			Kind:      10002,
+			CreatedAt: nostr.Now(),
+			Tags:      nostr.Tags{{"r", "wss://aaa.com"}, {"r", "wss://bbb.com", "read"}},
+		},
+		Items: []string{"wss://aaa.com", "wss://bbb.com"},
+	}
+
+	require.True(t, c.SetWithTTL(pk, v, time.Hour))
+	require.True(t, c.SetWithTTL("expired", v, time.Second))
+
+	got, ok := c.Get(pk)
+	require.True(t, ok)
+	require.Equal(t, v, got)
+
+	// values must still be there after a restart, but expired ones shouldn't
+	c.Close()
+	time.Sleep(time.Second * 2)
+	c, err = New32[testList](path)
+	require.NoError(t, err)
+	defer c.Close()
+
+	got, ok = c.Get(pk)
+	require.True(t, ok)
+	require.Equal(t, v, got)
+
+	_, ok = c.Get("expired")
+	require.False(t, ok)
+
+	c.Delete(pk)
+	_, ok = c.Get(pk)
+	require.False(t, ok)
+}
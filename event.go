@@ -0,0 +1,129 @@
+package sdk
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/nbd-wtf/go-nostr"
+	"github.com/nbd-wtf/go-nostr/nip19"
+	"github.com/nbd-wtf/nostr-sdk/hints"
+)
+
+// FetchEventFromInput takes a note, nevent, naddr or hex event id and returns the event it points to,
+// updating the hintsDB in the process with any eventual relay hints.
+//
+// it looks first in the local store, then in the relays given in the code, then in the author's outbox relays
+// and finally in the fallback relays. events found in relays are verified and saved in the local store.
+func (sys *System) FetchEventFromInput(ctx context.Context, code string) (*nostr.Event, error) {
+	var filter nostr.Filter
+	var author string
+	var hintRelays []string
+	hintType := hints.LastInNevent
+
+	if ep := InputToEventPointer(code); ep != nil {
+		filter.IDs = []string{ep.ID}
+		author = ep.Author
+		hintRelays = ep.Relays
+	} else if prefix, data, err := nip19.Decode(code); err == nil && prefix == "naddr" {
+		ap := data.(nostr.EntityPointer)
+		filter.Kinds = []int{ap.Kind}
+		filter.Authors = []string{ap.PublicKey}
+		if ap.Kind >= 30000 && ap.Kind < 40000 {
+			filter.Tags = nostr.TagMap{"d": []string{ap.Identifier}}
+		}
+		author = ap.PublicKey
+		hintRelays = ap.Relays
+		hintType = hints.LastInNaddr
+	} else {
+		return nil, fmt.Errorf("couldn't decode event reference")
+	}
+
+	return sys.fetchEvent(ctx, filter, author, hintRelays, hintType)
+}
+
+// fetchEvent looks for a single event matching the filter in the local store, then in the given relays,
+// then in the author's outbox relays (if the author is known) and finally in the fallback relays.
+// hintType tells where the given relays came from so they can be saved as hints for the author.
+func (sys *System) fetchEvent(
+	ctx context.Context,
+	filter nostr.Filter,
+	author string,
+	hintRelays []string,
+	hintType hints.HintKey,
+) (*nostr.Event, error) {
+	if author != "" && !nostr.IsValidPublicKey(author) {
+		author = ""
+	}
+
+	relays := make([]string, 0, len(hintRelays))
+	for _, r := range hintRelays {
+		nm := nostr.NormalizeURL(r)
+		if IsVirtualRelay(nm) || sys.IsRelayBlocked(nm) || slices.Contains(relays, nm) {
+			continue
+		}
+		relays = append(relays, nm)
+		if author != "" {
+			sys.Hints.Save(author, nm, hintType, nostr.Now())
+		}
+	}
+
+	// try our local store first
+	if res, _ := sys.StoreRelay.QuerySync(ctx, filter); len(res) != 0 {
+		return res[0], nil
+	}
+
+	attempts := [][]string{relays}
+	if author != "" {
+		attempts = append(attempts, sys.FetchOutboxRelays(ctx, author, 3))
+	}
+	attempts = append(attempts, sys.FallbackRelays)
+
+	tried := make([]string, 0, 12)
+	for _, attempt := range attempts {
+		urls := make([]string, 0, len(attempt))
+		for _, url := range attempt {
+			if !slices.Contains(tried, url) && !sys.IsRelayBlocked(url) {
+				urls = append(urls, url)
+				tried = append(tried, url)
+			}
+		}
+		if len(urls) == 0 {
+			continue
+		}
+
+		if evt := sys.queryVerifiedEvent(ctx, urls, filter); evt != nil {
+			sys.StoreRelay.Publish(ctx, *evt)
+			return evt, nil
+		}
+	}
+
+	return nil, fmt.Errorf("couldn't find event anywhere %v", tried)
+}
+
+// queryVerifiedEvent queries the given relays and returns the newest event that matches the filter and
+// has a valid signature, or nil. when querying by id it returns as soon as a valid event is found.
+func (sys *System) queryVerifiedEvent(ctx context.Context, urls []string, filter nostr.Filter) *nostr.Event {
+	ctx, cancel := context.WithTimeout(ctx, time.Second*4)
+	defer cancel()
+
+	var result *nostr.Event
+	for ie := range sys.Pool.SubManyEose(ctx, urls, nostr.Filters{filter}) {
+		if !filter.Matches(ie.Event) || ie.Event.GetID() != ie.Event.ID {
+			continue
+		}
+		if ok, _ := ie.Event.CheckSignature(); !ok {
+			continue
+		}
+
+		if result == nil || result.CreatedAt < ie.Event.CreatedAt {
+			result = ie.Event
+		}
+		if len(filter.IDs) != 0 {
+			break
+		}
+	}
+
+	return result
+}
@@ -2,10 +2,13 @@ package sdk
 
 import (
 	"context"
+	"fmt"
 	"net/url"
+	"slices"
 	"strings"
 
 	"github.com/nbd-wtf/go-nostr"
+	"github.com/nbd-wtf/nostr-sdk/keyring"
 )
 
 type FollowList = GenericList[Follow]
@@ -18,11 +21,39 @@ type Follow struct {
 
 func (f Follow) Value() string { return f.Pubkey }
 
+func (f Follow) ToTag() nostr.Tag { return trimTag(nostr.Tag{"p", f.Pubkey, f.Relay, f.Petname}) }
+
 func (sys *System) FetchFollowList(ctx context.Context, pubkey string) FollowList {
 	fl, _ := fetchGenericList[Follow](sys, ctx, pubkey, 3, parseFollow, sys.FollowListCache, false)
 	return fl
 }
 
+// Follow adds the given users to the signer's follow list and publishes it. Users already followed are left
+// as they are. See ModifyList for the safety checks performed.
+func (sys *System) Follow(ctx context.Context, signer keyring.Signer, follows ...Follow) (FollowList, []PublishResult, error) {
+	for _, f := range follows {
+		if !nostr.IsValidPublicKey(f.Pubkey) {
+			return FollowList{}, nil, fmt.Errorf("invalid pubkey '%s'", f.Pubkey)
+		}
+	}
+
+	return ModifyList(ctx, sys, signer, 3, func(fl *FollowList) {
+		for _, f := range follows {
+			if !slices.ContainsFunc(fl.Items, func(curr Follow) bool { return curr.Pubkey == f.Pubkey }) {
+				fl.Items = append(fl.Items, f)
+			}
+		}
+	})
+}
+
+// Unfollow removes the given pubkeys from the signer's follow list and publishes it.
+// See ModifyList for the safety checks performed.
+func (sys *System) Unfollow(ctx context.Context, signer keyring.Signer, pubkeys ...string) (FollowList, []PublishResult, error) {
+	return ModifyList(ctx, sys, signer, 3, func(fl *FollowList) {
+		fl.Items = slices.DeleteFunc(fl.Items, func(f Follow) bool { return slices.Contains(pubkeys, f.Pubkey) })
+	})
+}
+
 func parseFollow(tag nostr.Tag) (fw Follow, ok bool) {
 	if len(tag) < 2 {
 		return fw, false
@@ -43,8 +74,7 @@ func parseFollow(tag nostr.Tag) (fw Follow, ok bool) {
 		if len(tag) > 3 {
 			fw.Petname = strings.TrimSpace(tag[3])
 		}
-		return fw, true
 	}
 
-	return fw, false
+	return fw, true
 }
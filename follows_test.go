@@ -0,0 +1,28 @@
+package sdk
+
+import (
+	"testing"
+
+	"github.com/nbd-wtf/go-nostr"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFollow(t *testing.T) {
+	const alice = "3bf0c63fcb93463407af97a5e5ee64fa883d107ef9e558472c4eb9aaaefa459d"
+
+	// tags without relay and petname are the most common, they must not be ignored
+	f, ok := parseFollow(nostr.Tag{"p", alice})
+	require.True(t, ok)
+	require.Equal(t, Follow{Pubkey: alice}, f)
+
+	f, ok = parseFollow(nostr.Tag{"p", alice, "wss://relay.com", " alice "})
+	require.True(t, ok)
+	require.Equal(t, Follow{Pubkey: alice, Relay: "wss://relay.com", Petname: "alice"}, f)
+
+	_, ok = parseFollow(nostr.Tag{"p", "invalid"})
+	require.False(t, ok)
+	_, ok = parseFollow(nostr.Tag{"e", alice})
+	require.False(t, ok)
+	_, ok = parseFollow(nostr.Tag{"p"})
+	require.False(t, ok)
+}
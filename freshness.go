@@ -0,0 +1,90 @@
+package sdk
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/nbd-wtf/go-nostr"
+)
+
+// RefreshedValue is given to the System's OnRefresh callback when a background refresh finds a newer version
+// of something that was served from the cache or from the store. Value is a ProfileMetadata for kind 0 and the
+// corresponding GenericList for the other kinds (e.g. a FollowList for kind 3).
+type RefreshedValue struct {
+	PubKey string
+	Kind   int
+	Event  *nostr.Event
+	Value  any
+}
+
+// freshness keeps track of when we last asked relays for each replaceable event.
+type freshness struct {
+	sync.Mutex
+	checked map[string]time.Time // { [kind:pubkey]: when }
+}
+
+func newFreshness() *freshness {
+	return &freshness{checked: make(map[string]time.Time, 1000)}
+}
+
+// mark records that we have just asked relays for this.
+func (f *freshness) mark(kind int, pubkey string) {
+	f.Lock()
+	defer f.Unlock()
+	f.checked[strconv.Itoa(kind)+":"+pubkey] = time.Now()
+}
+
+// claim tells if we haven't asked relays for this in the given period and, if so, marks it as if we just had,
+// so only one refresh happens at a time.
+func (f *freshness) claim(kind int, pubkey string, period time.Duration) bool {
+	f.Lock()
+	defer f.Unlock()
+
+	key := strconv.Itoa(kind) + ":" + pubkey
+	now := time.Now()
+	if last, ok := f.checked[key]; ok && now.Sub(last) < period {
+		return false
+	}
+
+	if len(f.checked) > 50_000 {
+		// forget the oldest stuff so this doesn't grow forever
+		for k, last := range f.checked {
+			if now.Sub(last) > period {
+				delete(f.checked, k)
+			}
+		}
+	}
+
+	f.checked[key] = now
+	return true
+}
+
+// revalidate fetches a replaceable event again in the background if we haven't asked relays for it in more
+// than SoftTTL, calling onNewer if we get something newer than current (which may be nil).
+func (sys *System) revalidate(kind int, pubkey string, current *nostr.Event, onNewer func(*nostr.Event)) {
+	if sys.SoftTTL == 0 || !sys.freshness.claim(kind, pubkey, sys.SoftTTL) {
+		return
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+		defer cancel()
+
+		evt, err := sys.replaceableLoaders[kind].Load(ctx, pubkey)()
+		if err != nil || (current != nil && evt.CreatedAt <= current.CreatedAt) {
+			return
+		}
+
+		sys.StoreRelay.Publish(ctx, *evt)
+		sys.actingUserListSaved(evt)
+		onNewer(evt)
+	}()
+}
+
+func (sys *System) notifyRefresh(rv RefreshedValue) {
+	if sys.OnRefresh != nil {
+		sys.OnRefresh(rv)
+	}
+}
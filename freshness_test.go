@@ -0,0 +1,61 @@
+package sdk
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/graph-gophers/dataloader/v7"
+	"github.com/nbd-wtf/go-nostr"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSoftTTL(t *testing.T) {
+	ctx := context.Background()
+	refreshes := make(chan RefreshedValue, 10)
+	sys := NewSystem(WithSoftTTL(time.Minute, func(rv RefreshedValue) { refreshes <- rv }))
+
+	pubkey, _ := nostr.GetPublicKey(nostr.GeneratePrivateKey())
+	now := nostr.Now()
+	old := &nostr.Event{Kind: 0, PubKey: pubkey, CreatedAt: now - 100, Tags: nostr.Tags{}, Content: `{"name":"old"}`}
+	old.ID = old.GetID()
+	newer := &nostr.Event{Kind: 0, PubKey: pubkey, CreatedAt: now, Tags: nostr.Tags{}, Content: `{"name":"new"}`}
+	newer.ID = newer.GetID()
+
+	// relays will give us the newer version
+	sys.replaceableLoaders[0] = dataloader.NewBatchedLoader(
+		func(ctx context.Context, pubkeys []string) []*dataloader.Result[*nostr.Event] {
+			results := make([]*dataloader.Result[*nostr.Event], len(pubkeys))
+			for i := range pubkeys {
+				results[i] = &dataloader.Result[*nostr.Event]{Data: newer}
+			}
+			return results
+		},
+	)
+
+	// the stored version is served immediately
+	sys.Store.SaveEvent(ctx, old)
+	require.Equal(t, "old", sys.FetchProfileMetadata(ctx, pubkey).Name)
+
+	// then we're told about the newer one
+	select {
+	case rv := <-refreshes:
+		require.Equal(t, 0, rv.Kind)
+		require.Equal(t, pubkey, rv.PubKey)
+		require.Equal(t, "new", rv.Value.(ProfileMetadata).Name)
+	case <-time.After(time.Second * 5):
+		t.Fatal("no refresh")
+	}
+
+	stored, _ := sys.StoreRelay.QuerySync(ctx, nostr.Filter{Kinds: []int{0}, Authors: []string{pubkey}})
+	require.Len(t, stored, 1)
+	require.Equal(t, newer.ID, stored[0].ID)
+
+	// we don't refresh again before the soft ttl expires
+	sys.FetchProfileMetadata(ctx, pubkey)
+	select {
+	case <-refreshes:
+		t.Fatal("unexpected refresh")
+	case <-time.After(time.Millisecond * 100):
+	}
+}
@@ -0,0 +1,170 @@
+package badgerh
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"slices"
+
+	"github.com/dgraph-io/badger/v4"
+	"github.com/nbd-wtf/go-nostr"
+	"github.com/nbd-wtf/nostr-sdk/hints"
+	"github.com/nbd-wtf/nostr-sdk/hints/memory"
+)
+
+var _ hints.HintsDB = (*BadgerHints)(nil)
+
+// BadgerHints is a HintsDB that persists relay scores on disk so they survive restarts.
+//
+// each (pubkey, relay) pair is stored under a key made of the 32 raw pubkey bytes followed by
+// the relay URL, and its value holds the timestamps for each hints.HintKey, just like memory.RelayEntry.
+type BadgerHints struct {
+	db *badger.DB
+}
+
+func NewBadgerHints(path string) (*BadgerHints, error) {
+	opts := badger.DefaultOptions(path)
+	opts.Logger = nil
+	db, err := badger.Open(opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open badger at %s: %w", path, err)
+	}
+	return &BadgerHints{db: db}, nil
+}
+
+func (bh *BadgerHints) Close() {
+	bh.db.Close()
+}
+
+func (bh *BadgerHints) Save(pubkey string, relay string, key hints.HintKey, ts nostr.Timestamp) {
+	k, ok := encodeKey(pubkey, relay)
+	if !ok {
+		return
+	}
+
+	bh.db.Update(func(txn *badger.Txn) error {
+		var entry memory.RelayEntry
+
+		item, err := txn.Get(k)
+		if err == nil {
+			if err := item.Value(func(val []byte) error {
+				entry.Timestamps = decodeTimestamps(val)
+				return nil
+			}); err != nil {
+				return err
+			}
+		} else if err != badger.ErrKeyNotFound {
+			return err
+		}
+
+		if entry.Timestamps[key] >= ts {
+			// no need to update anything
+			return nil
+		}
+		entry.Timestamps[key] = ts
+
+		return txn.Set(k, encodeTimestamps(entry.Timestamps))
+	})
+}
+
+func (bh *BadgerHints) TopN(pubkey string, n int) []string {
+	prefix, err := hex.DecodeString(pubkey)
+	if err != nil || len(prefix) != 32 {
+		return nil
+	}
+
+	type scoredRelay struct {
+		url   string
+		score int64
+	}
+	scored := make([]scoredRelay, 0, n)
+
+	bh.db.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.Prefix = prefix
+		it := txn.NewIterator(opts)
+		defer it.Close()
+
+		for it.Rewind(); it.Valid(); it.Next() {
+			item := it.Item()
+			url := string(item.Key()[32:])
+			if err := item.Value(func(val []byte) error {
+				entry := memory.RelayEntry{Timestamps: decodeTimestamps(val)}
+				scored = append(scored, scoredRelay{url, entry.Sum()})
+				return nil
+			}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	slices.SortFunc(scored, func(a, b scoredRelay) int {
+		return int(b.score - a.score)
+	})
+
+	urls := make([]string, 0, n)
+	for i, sr := range scored {
+		urls = append(urls, sr.url)
+		if i+1 == n {
+			break
+		}
+	}
+	return urls
+}
+
+func (bh *BadgerHints) PrintScores() {
+	fmt.Println("= print scores")
+	bh.db.View(func(txn *badger.Txn) error {
+		it := txn.NewIterator(badger.DefaultIteratorOptions)
+		defer it.Close()
+
+		var last string
+		i := 0
+		for it.Rewind(); it.Valid(); it.Next() {
+			item := it.Item()
+			pubkey := hex.EncodeToString(item.Key()[0:32])
+			if pubkey != last {
+				fmt.Println("== relay scores for", pubkey)
+				last = pubkey
+				i = 0
+			}
+			item.Value(func(val []byte) error {
+				entry := memory.RelayEntry{Timestamps: decodeTimestamps(val)}
+				fmt.Printf("  %3d :: %30s ::> %12d\n", i, string(item.Key()[32:]), entry.Sum())
+				return nil
+			})
+			i++
+		}
+		return nil
+	})
+}
+
+func encodeKey(pubkey string, relay string) ([]byte, bool) {
+	if len(pubkey) != 64 {
+		return nil, false
+	}
+	k := make([]byte, 32, 32+len(relay))
+	if _, err := hex.Decode(k, []byte(pubkey)); err != nil {
+		return nil, false
+	}
+	return append(k, relay...), true
+}
+
+func encodeTimestamps(tss [8]nostr.Timestamp) []byte {
+	v := make([]byte, 4*len(tss))
+	for i, ts := range tss {
+		binary.BigEndian.PutUint32(v[i*4:], uint32(ts))
+	}
+	return v
+}
+
+func decodeTimestamps(v []byte) (tss [8]nostr.Timestamp) {
+	for i := range tss {
+		if len(v) < (i+1)*4 {
+			break
+		}
+		tss[i] = nostr.Timestamp(binary.BigEndian.Uint32(v[i*4:]))
+	}
+	return tss
+}
@@ -0,0 +1,67 @@
+package badgerh
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nbd-wtf/go-nostr"
+	"github.com/nbd-wtf/nostr-sdk/hints"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRelayPickingPersisted(t *testing.T) {
+	path := t.TempDir()
+	hdb, err := NewBadgerHints(path)
+	require.NoError(t, err)
+
+	const key1 = "0000000000000000000000000000000000000000000000000000000000000001"
+	const key2 = "0000000000000000000000000000000000000000000000000000000000000002"
+	const relayA = "wss://aaa.com"
+	const relayB = "wss://bbb.online"
+	const relayC = "wss://ccc.technology"
+
+	hour := nostr.Timestamp((time.Hour).Seconds())
+	day := hour * 24
+
+	hdb.Save(key1, relayA, hints.LastInTag, nostr.Now()-60*hour)
+	hdb.Save(key1, relayB, hints.LastInRelayList, nostr.Now()-day*10)
+	hdb.Save(key1, relayB, hints.LastInNevent, nostr.Now()-day*30)
+	hdb.Save(key1, relayA, hints.LastInNprofile, nostr.Now()-hour*10)
+	hdb.PrintScores()
+
+	require.Equal(t, []string{relayB, relayA}, hdb.TopN(key1, 3))
+
+	hdb.Save(key1, relayA, hints.LastFetchAttempt, nostr.Now()-5*hour)
+	hdb.Save(key1, relayC, hints.LastInNIP05, nostr.Now()-5*hour)
+	hdb.PrintScores()
+
+	require.Equal(t, []string{relayB, relayC, relayA}, hdb.TopN(key1, 3))
+
+	// older timestamps must not override newer ones
+	hdb.Save(key1, relayC, hints.LastInNIP05, nostr.Now()-day*100)
+	require.Equal(t, []string{relayB, relayC, relayA}, hdb.TopN(key1, 3))
+
+	hdb.Save(key2, relayA, hints.LastInRelayList, nostr.Now()-day*25)
+	hdb.Save(key2, relayB, hints.LastInRelayList, nostr.Now()-day*25)
+	hdb.Save(key2, relayC, hints.LastInTag, nostr.Now()-5*hour)
+	hdb.Save(key2, relayC, hints.LastInNIP05, nostr.Now()-5*hour)
+	hdb.Save(key2, relayC, hints.LastInNevent, nostr.Now()-5*hour)
+	hdb.Save(key2, relayC, hints.LastInNprofile, nostr.Now()-5*hour)
+	hdb.PrintScores()
+
+	require.Equal(t, relayC, hdb.TopN(key2, 3)[0])
+	require.Len(t, hdb.TopN(key2, 2), 2)
+
+	// everything must still be there after a restart
+	hdb.Close()
+	hdb, err = NewBadgerHints(path)
+	require.NoError(t, err)
+	defer hdb.Close()
+
+	require.Equal(t, []string{relayB, relayC, relayA}, hdb.TopN(key1, 3))
+	require.Equal(t, relayC, hdb.TopN(key2, 3)[0])
+
+	// invalid keys are just ignored
+	hdb.Save("abc", relayA, hints.LastInTag, nostr.Now())
+	require.Empty(t, hdb.TopN("abc", 3))
+}
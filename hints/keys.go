@@ -14,9 +14,10 @@ const (
 	LastInNprofile
 	LastInNevent
 	LastInNIP05
+	LastInNaddr
 )
 
-var KeyBasePoints = [7]int64{
+var KeyBasePoints = [8]int64{
 	-500, // attempting has negative power because it may fail
 	700,  // when it succeeds that should cancel the negative effect of trying
 	350,  // a relay list is a very strong indicator
@@ -24,6 +25,7 @@ var KeyBasePoints = [7]int64{
 	22,   // it feels like people take nprofiles slightly more seriously so we value these a bit more
 	8,    // these are also not often too bad
 	7,    // nip05 hints should be a strong indicator, although in practice they're kinda bad
+	8,    // same as nevent
 }
 
 func (hk HintKey) BasePoints() int64 { return KeyBasePoints[hk] }
@@ -44,6 +46,8 @@ func (hk HintKey) String() string {
 		return "last_in_nevent"
 	case LastInNIP05:
 		return "last_in_nip05"
+	case LastInNaddr:
+		return "last_in_naddr"
 	}
 	return "<unexpected>"
 }
@@ -35,5 +35,9 @@ func (bs BunkerSigner) Encrypt(ctx context.Context, plaintext string, recipient
 }
 
 func (bs BunkerSigner) Decrypt(ctx context.Context, base64ciphertext string, sender string) (plaintext string, err error) {
-	return bs.bunker.NIP44Encrypt(ctx, sender, base64ciphertext)
+	return bs.bunker.NIP44Decrypt(ctx, sender, base64ciphertext)
+}
+
+func (bs BunkerSigner) NIP04Decrypt(ctx context.Context, ciphertext string, sender string) (plaintext string, err error) {
+	return bs.bunker.RPC(ctx, "nip04_decrypt", []string{sender, ciphertext})
 }
@@ -5,6 +5,7 @@ import (
 	"fmt"
 
 	"github.com/nbd-wtf/go-nostr"
+	"github.com/nbd-wtf/go-nostr/nip04"
 	"github.com/nbd-wtf/go-nostr/nip44"
 	"github.com/nbd-wtf/go-nostr/nip49"
 )
@@ -63,5 +64,18 @@ func (es EncryptedKeySigner) Decrypt(ctx context.Context, base64ciphertext strin
 	if err != nil {
 		return "", err
 	}
-	return nip44.Encrypt(plaintext, ck)
+	return nip44.Decrypt(base64ciphertext, ck)
+}
+
+func (es EncryptedKeySigner) NIP04Decrypt(ctx context.Context, ciphertext string, sender string) (plaintext string, err error) {
+	password := es.callback(ctx)
+	sk, err := nip49.Decrypt(es.ncryptsec, password)
+	if err != nil {
+		return "", fmt.Errorf("invalid password: %w", err)
+	}
+	key, err := nip04.ComputeSharedSecret(sender, sk)
+	if err != nil {
+		return "", err
+	}
+	return nip04.Decrypt(ciphertext, key)
 }
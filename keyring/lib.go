@@ -30,6 +30,12 @@ type Cipher interface {
 	Decrypt(ctx context.Context, base64ciphertext string, senderPublicKey string) (plaintext string, err error)
 }
 
+// A NIP04Decrypter can also decrypt legacy NIP-04 ciphertexts, which are still found in the private items
+// of some old NIP-51 lists. All the signers in this package implement it.
+type NIP04Decrypter interface {
+	NIP04Decrypt(ctx context.Context, ciphertext string, senderPublicKey string) (plaintext string, err error)
+}
+
 type SignerOptions struct {
 	BunkerClientSecretKey string
 	BunkerSignTimeout     time.Duration
@@ -2,6 +2,7 @@ package keyring
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/nbd-wtf/go-nostr"
 )
@@ -14,6 +15,7 @@ type ManualSigner struct {
 	ManualSignEvent    func(context.Context, *nostr.Event) error
 	ManualEncrypt      func(ctx context.Context, plaintext string, recipientPublicKey string) (base64ciphertext string, err error)
 	ManualDecrypt      func(ctx context.Context, base64ciphertext string, senderPublicKey string) (plaintext string, err error)
+	ManualNIP04Decrypt func(ctx context.Context, ciphertext string, senderPublicKey string) (plaintext string, err error) // optional
 }
 
 func (ms ManualSigner) SignEvent(ctx context.Context, evt *nostr.Event) error {
@@ -31,3 +33,10 @@ func (ms ManualSigner) Encrypt(ctx context.Context, plaintext string, recipient
 func (ms ManualSigner) Decrypt(ctx context.Context, base64ciphertext string, sender string) (plaintext string, err error) {
 	return ms.ManualDecrypt(ctx, base64ciphertext, sender)
 }
+
+func (ms ManualSigner) NIP04Decrypt(ctx context.Context, ciphertext string, sender string) (plaintext string, err error) {
+	if ms.ManualNIP04Decrypt == nil {
+		return "", fmt.Errorf("NIP-04 decryption not provided")
+	}
+	return ms.ManualNIP04Decrypt(ctx, ciphertext, sender)
+}
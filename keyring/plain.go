@@ -4,6 +4,7 @@ import (
 	"context"
 
 	"github.com/nbd-wtf/go-nostr"
+	"github.com/nbd-wtf/go-nostr/nip04"
 	"github.com/nbd-wtf/go-nostr/nip44"
 )
 
@@ -40,5 +41,13 @@ func (ks KeySigner) Decrypt(ctx context.Context, base64ciphertext string, sender
 		}
 		ks.conversationKeys[sender] = ck
 	}
-	return nip44.Encrypt(plaintext, ck)
+	return nip44.Decrypt(base64ciphertext, ck)
+}
+
+func (ks KeySigner) NIP04Decrypt(ctx context.Context, ciphertext string, sender string) (plaintext string, err error) {
+	key, err := nip04.ComputeSharedSecret(sender, ks.sk)
+	if err != nil {
+		return "", err
+	}
+	return nip04.Decrypt(ciphertext, key)
 }
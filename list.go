@@ -2,11 +2,13 @@ package sdk
 
 import (
 	"context"
+	"fmt"
 	"slices"
 	"time"
 
 	"github.com/nbd-wtf/go-nostr"
 	"github.com/nbd-wtf/nostr-sdk/cache"
+	"github.com/nbd-wtf/nostr-sdk/keyring"
 )
 
 type GenericList[I TagItemWithValue] struct {
@@ -14,10 +16,32 @@ type GenericList[I TagItemWithValue] struct {
 	Event  *nostr.Event `json:"-"` // may be empty if a contact list event wasn't found
 
 	Items []I
+
+	// items that come from the encrypted part of the list, by their Value(). nil when that wasn't decrypted,
+	// which only happens for lists of the acting user when a cipher was given with SetActingUserCipher, and
+	// only if it could decrypt them (see keyring.NIP04Decrypter for lists encrypted with NIP-04).
+	Private map[string]bool `json:"-"`
+}
+
+// IsPrivate tells if the given item is in the encrypted part of the list.
+func (l GenericList[I]) IsPrivate(item I) bool { return l.Private[item.Value()] }
+
+// SetPrivate marks an item as private or public, so ModifyList saves it in the encrypted or in the public part
+// of the list.
+func (l *GenericList[I]) SetPrivate(item I, private bool) {
+	if l.Private == nil {
+		l.Private = make(map[string]bool)
+	}
+	if private {
+		l.Private[item.Value()] = true
+	} else {
+		delete(l.Private, item.Value())
+	}
 }
 
 type TagItemWithValue interface {
 	Value() string
+	ToTag() nostr.Tag
 }
 
 func fetchGenericList[I TagItemWithValue](
@@ -29,8 +53,22 @@ func fetchGenericList[I TagItemWithValue](
 	cache cache.Cache32[GenericList[I]],
 	skipFetch bool,
 ) (fl GenericList[I], fromInternal bool) {
+	// when serving from the cache or the store, refresh in the background if needed
+	refreshed := func(evt *nostr.Event) {
+		v := GenericList[I]{PubKey: pubkey, Event: evt, Items: parseItemsFromEventTags(evt, parseTag)}
+		if cache != nil {
+			cache.SetWithTTL(pubkey, v, time.Hour*6)
+		}
+		mergeActingUserPrivateItems(context.Background(), sys, &v, parseTag)
+		sys.notifyRefresh(RefreshedValue{PubKey: pubkey, Kind: kind, Event: evt, Value: v})
+	}
+
 	if cache != nil {
 		if v, ok := cache.Get(pubkey); ok {
+			mergeActingUserPrivateItems(ctx, sys, &v, parseTag)
+			if !skipFetch {
+				sys.revalidate(kind, pubkey, v.Event, refreshed)
+			}
 			return v, true
 		}
 	}
@@ -43,21 +81,31 @@ func fetchGenericList[I TagItemWithValue](
 			Event:  events[0],
 			Items:  items,
 		}
-		cache.SetWithTTL(pubkey, v, time.Hour*6)
+		if cache != nil {
+			cache.SetWithTTL(pubkey, v, time.Hour*6)
+		}
+		mergeActingUserPrivateItems(ctx, sys, &v, parseTag)
+		if !skipFetch {
+			sys.revalidate(kind, pubkey, v.Event, refreshed)
+		}
 		return v, true
 	}
 
 	v := GenericList[I]{PubKey: pubkey}
 	if !skipFetch {
+		sys.freshness.mark(kind, pubkey)
 		thunk := sys.replaceableLoaders[kind].Load(ctx, pubkey)
 		evt, err := thunk()
 		if err == nil {
 			items := parseItemsFromEventTags(evt, parseTag)
+			v.Event = evt
 			v.Items = items
 			if cache != nil {
 				cache.SetWithTTL(pubkey, v, time.Hour*6)
 			}
+			mergeActingUserPrivateItems(ctx, sys, &v, parseTag)
 			sys.StoreRelay.Publish(ctx, *evt)
+			sys.actingUserListSaved(evt)
 		}
 	}
 
@@ -80,3 +128,280 @@ func parseItemsFromEventTags[I TagItemWithValue](
 	}
 	return result
 }
+
+// ModifyList fetches the freshest version of the signer's list of the given kind from as many relays as possible,
+// applies the given change to its items (adding, removing or reordering them) and publishes the result, also
+// refreshing the corresponding cache. I must be the item type for that kind, i.e. Follow for kind 3.
+//
+// tags that aren't recognized as items and the content are kept untouched, and so are the original tags of the
+// items that weren't changed.
+//
+// if the signer is also a keyring.Cipher the private items of NIP-51 lists are decrypted before the change and
+// items marked as private are encrypted again in the content afterwards. without it private items can't be saved.
+//
+// since a list is always overwritten entirely, a list that is out-of-date or was wiped by a buggy client would
+// make the user lose their items, so this refuses to proceed when no relay has the list but we know one exists,
+// or when the latest list is empty but we know of one that wasn't. relays only count as reached when they send
+// an EOSE, and a new list is only created when at least two of them (one being an indexer, if the kind has
+// any) said they don't have one.
+func ModifyList[I TagItemWithValue](
+	ctx context.Context,
+	sys *System,
+	signer keyring.Signer,
+	kind int,
+	change func(*GenericList[I]),
+) (GenericList[I], []PublishResult, error) {
+	pubkey := signer.GetPublicKey(ctx)
+	l := GenericList[I]{PubKey: pubkey}
+
+	parseTag, cache, err := listHandlers[I](sys, kind)
+	if err != nil {
+		return l, nil, err
+	}
+
+	latest := sys.fetchLatestReplaceable(ctx, pubkey, kind)
+
+	// what we knew about this list before
+	known := latest.Local
+	if cache != nil {
+		if cached, ok := cache.Get(pubkey); ok && cached.Event != nil &&
+			(known == nil || cached.Event.CreatedAt > known.CreatedAt) {
+			known = cached.Event
+		}
+	}
+
+	if err := latest.checkSafeToReplace(known, len(sys.indexerRelays(kind)) > 0); err != nil {
+		return l, nil, fmt.Errorf("can't modify list: %w", err)
+	}
+
+	// if we can't decrypt the private tags we'll keep the content as it is
+	var privateTags nostr.Tags
+	cipher, _ := signer.(keyring.Cipher)
+	if cipher != nil && supportsPrivateItems(kind) {
+		if latest.Event == nil {
+			privateTags = nostr.Tags{}
+		} else if tags, err := decryptPrivateTags(ctx, cipher, latest.Event); err == nil {
+			privateTags = tags
+		}
+	}
+
+	if latest.Event != nil && known != nil && countListItems(ctx, latest.Event, parseTag, cipher) == 0 {
+		if n := countListItems(ctx, known, parseTag, cipher); n > 0 {
+			return l, nil, fmt.Errorf("latest list is empty, but we know an older one with %d items", n)
+		}
+	}
+
+	evt, newPrivateTags, changed := applyListChange(latest.Event, pubkey, kind, parseTag, privateTags, change)
+	if !changed {
+		l.Event = latest.Event
+		l.Items = parseItemsFromEventTags(latest.Event, parseTag)
+		if privateTags != nil {
+			mergePrivateItems(&l, privateTags, parseTag)
+		}
+		return l, nil, nil
+	}
+
+	if privateTags != nil {
+		if evt.Content, err = encryptPrivateTags(ctx, cipher, pubkey, newPrivateTags); err != nil {
+			return l, nil, err
+		}
+	} else if len(newPrivateTags) > 0 {
+		return l, nil, fmt.Errorf("can't save private items without decrypting the existing ones with a keyring.Cipher")
+	}
+
+	extraRelays := sys.indexerRelays(kind)
+	if kind == 10002 {
+		// relay lists must also be on all the relays they mention, both the old and the new ones
+		extraRelays = append(slices.Clone(extraRelays), relayListURLs(latest.Event)...)
+		extraRelays = append(extraRelays, relayListURLs(&evt)...)
+	}
+
+	evt.CreatedAt = nextTimestamp(latest.Event)
+	evt, results, err := sys.publish(ctx, signer, evt, extraRelays)
+	if err != nil {
+		return l, results, err
+	}
+
+	sys.actingUserListSaved(&evt)
+
+	l.Event = &evt
+	l.Items = parseItemsFromEventTags(&evt, parseTag)
+	if cache != nil {
+		cache.SetWithTTL(pubkey, l, time.Hour*6)
+	}
+	if privateTags != nil {
+		sys.rememberActingUserPrivateTags(&evt, newPrivateTags)
+		l.Items = slices.Clone(l.Items)
+		mergePrivateItems(&l, newPrivateTags, parseTag)
+	}
+
+	return l, results, nil
+}
+
+// countListItems counts the public and the private items in a list event. when the private items can't be
+// decrypted they count as one item if there is anything encrypted, since we can't know how many they are.
+func countListItems[I TagItemWithValue](
+	ctx context.Context,
+	evt *nostr.Event,
+	parseTag func(nostr.Tag) (I, bool),
+	cipher keyring.Cipher,
+) int {
+	n := len(parseItemsFromEventTags(evt, parseTag))
+	if !supportsPrivateItems(evt.Kind) || evt.Content == "" {
+		return n
+	}
+
+	if cipher != nil {
+		if tags, err := decryptPrivateTags(ctx, cipher, evt); err == nil {
+			for _, tag := range tags {
+				if _, ok := parseTag(tag); ok {
+					n++
+				}
+			}
+			return n
+		}
+	}
+	return n + 1
+}
+
+// applyListChange builds a new list event by applying the given change to the items in base (which may be nil)
+// and to the items in privateTags (which may be nil if they aren't known). unrecognized tags come first, then the
+// items in their new order, and the same goes for the private tags that should be encrypted in the content.
+// it also tells if anything has changed.
+func applyListChange[I TagItemWithValue](
+	base *nostr.Event,
+	pubkey string,
+	kind int,
+	parseTag func(nostr.Tag) (I, bool),
+	privateTags nostr.Tags,
+	change func(*GenericList[I]),
+) (evt nostr.Event, newPrivateTags nostr.Tags, changed bool) {
+	evt = nostr.Event{Kind: kind, PubKey: pubkey, Tags: nostr.Tags{}}
+	newPrivateTags = nostr.Tags{}
+	l := GenericList[I]{PubKey: pubkey, Event: base}
+
+	originals := make(map[string]nostr.Tag) // { [item value]: tag it was parsed from }
+	previous := make([]string, 0)           // item values in their original order, prefixed if private
+	add := func(tag nostr.Tag, private bool) (recognized bool) {
+		item, ok := parseTag(tag)
+		if !ok {
+			return false
+		}
+		if _, ok := originals[item.Value()]; !ok {
+			originals[item.Value()] = tag
+			previous = append(previous, privacyKey(item.Value(), private))
+			l.Items = append(l.Items, item)
+			if private {
+				l.SetPrivate(item, true)
+			}
+		}
+		return true
+	}
+
+	if base != nil {
+		evt.Content = base.Content
+		for _, tag := range base.Tags {
+			if !add(tag, false) {
+				evt.Tags = append(evt.Tags, tag)
+			}
+		}
+	}
+	if privateTags != nil {
+		l.Private = make(map[string]bool)
+		for _, tag := range privateTags {
+			if !add(tag, true) {
+				newPrivateTags = append(newPrivateTags, tag)
+			}
+		}
+	}
+
+	change(&l)
+
+	current := make([]string, 0, len(l.Items))
+	for _, item := range l.Items {
+		value := item.Value()
+		private := l.IsPrivate(item)
+		if slices.ContainsFunc(current, func(key string) bool {
+			return key == privacyKey(value, true) || key == privacyKey(value, false)
+		}) {
+			continue
+		}
+		current = append(current, privacyKey(value, private))
+
+		tag := item.ToTag()
+		if orig, ok := originals[value]; ok {
+			if parsed, _ := parseTag(orig); slices.Equal(parsed.ToTag(), tag) {
+				// this item wasn't modified, so keep whatever else was in its tag
+				tag = orig
+			} else {
+				changed = true
+			}
+		}
+
+		if private {
+			newPrivateTags = append(newPrivateTags, tag)
+		} else {
+			evt.Tags = append(evt.Tags, tag)
+		}
+	}
+
+	return evt, newPrivateTags, changed || !slices.Equal(previous, current)
+}
+
+func privacyKey(value string, private bool) string {
+	if private {
+		return "private:" + value
+	}
+	return "public:" + value
+}
+
+// listHandlers returns the tag parser and the cache used for lists of the given kind.
+func listHandlers[I TagItemWithValue](sys *System, kind int) (
+	func(nostr.Tag) (I, bool),
+	cache.Cache32[GenericList[I]],
+	error,
+) {
+	var parser, c any
+	switch kind {
+	case 3:
+		parser, c = parseFollow, sys.FollowListCache
+	case 10000:
+		parser, c = parseMute, sys.muteListCache()
+	case 10001:
+		parser, c = parseEventRef, sys.PinnedNotesCache
+	case 10002:
+		parser, c = parseRelayFromKind10002, sys.RelayListCache
+	case 10003:
+		parser, c = parseBookmark, sys.BookmarkListCache
+	case 10004:
+		parser, c = parseCommunity, sys.CommunityListCache
+	case 10005:
+		parser, c = parseEventRef, sys.PublicChatListCache
+	case 10006:
+		parser, c = parseRelayURL, sys.BlockedRelayListCache
+	case 10007:
+		parser, c = parseRelayURL, sys.SearchRelayListCache
+	case 10015:
+		parser, c = parseInterest, sys.InterestListCache
+	case 10030:
+		parser, c = parseEmoji, sys.EmojiListCache
+	default:
+		return nil, nil, fmt.Errorf("kind %d is not a supported list", kind)
+	}
+
+	parseTag, ok := parser.(func(nostr.Tag) (I, bool))
+	if !ok {
+		var zero I
+		return nil, nil, fmt.Errorf("kind %d is not a list of %T", kind, zero)
+	}
+	typed, _ := c.(cache.Cache32[GenericList[I]]) // may be nil
+	return parseTag, typed, nil
+}
+
+// trimTag removes empty optional values from the end of a tag.
+func trimTag(tag nostr.Tag) nostr.Tag {
+	for len(tag) > 2 && tag[len(tag)-1] == "" {
+		tag = tag[0 : len(tag)-1]
+	}
+	return tag
+}
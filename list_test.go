@@ -0,0 +1,304 @@
+package sdk
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nbd-wtf/go-nostr"
+	"github.com/nbd-wtf/go-nostr/nip04"
+	cache_memory "github.com/nbd-wtf/nostr-sdk/cache/memory"
+	"github.com/nbd-wtf/nostr-sdk/keyring"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/websocket"
+)
+
+func TestApplyListChange(t *testing.T) {
+	const alice = "3bf0c63fcb93463407af97a5e5ee64fa883d107ef9e558472c4eb9aaaefa459d"
+	const bob = "f30f50c0fe3ae0ae6cf1a7b6fb1473cdd92b02a8f8b3054495e8469dfe595c4a"
+	const carol = "79be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798"
+
+	base := &nostr.Event{
+		Kind:    3,
+		Content: `{"wss://relay.com":{"read":true,"write":true}}`,
+		Tags: nostr.Tags{
+			{"p", alice, "wss://alice.com/", "alice", "something else"},
+			{"t", "unrelated"},
+			{"p", bob},
+			{"p", bob, "", "duplicate"},
+		},
+	}
+
+	// nothing changes
+	_, _, changed := applyListChange(base, "me", 3, parseFollow, nil, func(fl *FollowList) {})
+	require.False(t, changed)
+
+	// add, remove and modify
+	evt, _, changed := applyListChange(base, "me", 3, parseFollow, nil, func(fl *FollowList) {
+		fl.Items = slices.DeleteFunc(fl.Items, func(f Follow) bool { return f.Pubkey == alice })
+		fl.Items[0].Petname = "bob"
+		fl.Items = append(fl.Items, Follow{Pubkey: carol, Relay: "wss://carol.com"})
+	})
+	require.True(t, changed)
+	require.Equal(t, 3, evt.Kind)
+	require.Equal(t, base.Content, evt.Content)
+	require.Equal(t, nostr.Tags{
+		{"t", "unrelated"},
+		{"p", bob, "", "bob"},
+		{"p", carol, "wss://carol.com"},
+	}, evt.Tags)
+
+	// reordering counts as a change and untouched items keep their original tags
+	evt, _, changed = applyListChange(base, "me", 3, parseFollow, nil, func(fl *FollowList) {
+		slices.Reverse(fl.Items)
+	})
+	require.True(t, changed)
+	require.Equal(t, nostr.Tags{
+		{"t", "unrelated"},
+		{"p", bob},
+		{"p", alice, "wss://alice.com/", "alice", "something else"},
+	}, evt.Tags)
+
+	// creating a new list
+	evt, _, changed = applyListChange(nil, "me", 10000, parseMute, nil, func(ml *GenericList[Mute]) {
+		ml.Items = append(ml.Items, Mute{Tag: "t", Target: "spam"}, Mute{Tag: "t", Target: "spam"})
+	})
+	require.True(t, changed)
+	require.Equal(t, nostr.Tags{{"t", "spam"}}, evt.Tags)
+}
+
+func TestPrivateListItems(t *testing.T) {
+	ctx := context.Background()
+	pk, _ := nostr.GetPublicKey(nostr.GeneratePrivateKey())
+
+	// we only care about the encryption being reversible here
+	kr := keyring.ManualSigner{
+		ManualEncrypt: func(ctx context.Context, plaintext string, recipient string) (string, error) {
+			return base64.StdEncoding.EncodeToString([]byte(recipient + plaintext)), nil
+		},
+		ManualDecrypt: func(ctx context.Context, ciphertext string, sender string) (string, error) {
+			plaintext, err := base64.StdEncoding.DecodeString(ciphertext)
+			return strings.TrimPrefix(string(plaintext), sender), err
+		},
+	}
+
+	const alice = "3bf0c63fcb93463407af97a5e5ee64fa883d107ef9e558472c4eb9aaaefa459d"
+	private := nostr.Tags{{"p", alice}, {"word", "secret"}, {"unknown", "thing"}}
+	content, err := encryptPrivateTags(ctx, kr, pk, private)
+	require.NoError(t, err)
+
+	base := &nostr.Event{Kind: 10000, PubKey: pk, CreatedAt: nostr.Now(), Tags: nostr.Tags{{"t", "public"}}, Content: content}
+	base.ID = base.GetID()
+
+	decrypted, err := decryptPrivateTags(ctx, kr, base)
+	require.NoError(t, err)
+	require.Equal(t, private, decrypted)
+
+	// private items are merged into the acting user's lists
+	sys := NewSystem()
+	sys.acting.pubkey = pk
+	sys.SetActingUserCipher(kr)
+	sys.Store.SaveEvent(ctx, base)
+
+	ml := sys.FetchMuteList(ctx, pk)
+	require.Len(t, ml.Items, 3)
+	require.False(t, ml.IsPrivate(Mute{Tag: "t", Target: "public"}))
+	require.True(t, ml.IsPrivate(Mute{Tag: "p", Target: alice}))
+	require.True(t, ml.IsMuted(&nostr.Event{PubKey: alice}))
+	require.True(t, ml.IsMuted(&nostr.Event{Content: "a SECRET"}))
+
+	// but they are never cached, so they're gone when the acting user changes
+	sys.MuteListCache.(*cache_memory.RistrettoCache[MuteList]).Cache.Wait()
+	cached, _ := sys.MuteListCache.Get(pk)
+	require.Len(t, cached.Items, 1)
+	sys.SetActingUser(ctx, "")
+	require.Len(t, sys.FetchMuteList(ctx, pk).Items, 1)
+
+	// items can be moved between the public and the private parts
+	evt, newPrivate, changed := applyListChange(base, pk, 10000, parseMute, decrypted, func(ml *GenericList[Mute]) {
+		for _, m := range ml.Items {
+			ml.SetPrivate(m, !ml.IsPrivate(m))
+		}
+	})
+	require.True(t, changed)
+	require.Equal(t, nostr.Tags{{"p", alice}, {"word", "secret"}}, evt.Tags)
+	require.Equal(t, nostr.Tags{{"unknown", "thing"}, {"t", "public"}}, newPrivate)
+
+	// nothing changes if we don't touch anything
+	_, _, changed = applyListChange(base, pk, 10000, parseMute, decrypted, func(ml *GenericList[Mute]) {})
+	require.False(t, changed)
+}
+
+func TestPrivateListItemsNIP04(t *testing.T) {
+	ctx := context.Background()
+	sk := nostr.GeneratePrivateKey()
+	pk, _ := nostr.GetPublicKey(sk)
+	kr, err := keyring.New(ctx, nil, sk, nil)
+	require.NoError(t, err)
+
+	// old clients encrypted private items with NIP-04
+	key, _ := nip04.ComputeSharedSecret(pk, sk)
+	content, _ := nip04.Encrypt(`[["p","3bf0c63fcb93463407af97a5e5ee64fa883d107ef9e558472c4eb9aaaefa459d"]]`, key)
+	evt := &nostr.Event{Kind: 10000, PubKey: pk, Tags: nostr.Tags{}, Content: content}
+
+	tags, err := decryptPrivateTags(ctx, kr, evt)
+	require.NoError(t, err)
+	require.Len(t, tags, 1)
+
+	// a list with only private items is not empty
+	require.Equal(t, 1, countListItems(ctx, evt, parseMute, kr))
+	require.Equal(t, 1, countListItems[Mute](ctx, evt, parseMute, nil))
+	require.Equal(t, 0, countListItems(ctx, &nostr.Event{Kind: 10000, Tags: nostr.Tags{}}, parseMute, kr))
+}
+
+func TestRelayListTags(t *testing.T) {
+	base := &nostr.Event{Kind: 10002, Tags: nostr.Tags{{"r", "wss://both.com"}, {"r", "wss://read.com", "read"}}}
+
+	evt, _, changed := applyListChange(base, "me", 10002, parseRelayFromKind10002, nil, func(rl *RelayList) {
+		rl.Items[0].Inbox = false
+		rl.Items = append(rl.Items, Relay{URL: "wss://new.com", Inbox: true, Outbox: true})
+	})
+	require.True(t, changed)
+	require.Equal(t, nostr.Tags{
+		{"r", "wss://both.com", "write"},
+		{"r", "wss://read.com", "read"},
+		{"r", "wss://new.com"},
+	}, evt.Tags)
+	require.Equal(t, []string{"wss://both.com", "wss://read.com", "wss://new.com"}, relayListURLs(&evt))
+
+	// relays that are neither inbox nor outbox are removed instead of being written as both
+	evt, _, changed = applyListChange(base, "me", 10002, parseRelayFromKind10002, nil, func(rl *RelayList) {
+		rl.Items[0].Inbox = false
+		rl.Items[0].Outbox = false
+		rl.Items = append(rl.Items, Relay{URL: "not a url", Inbox: true}, Relay{URL: "wss://new.com/", Outbox: true})
+		cleanRelayList(rl)
+	})
+	require.True(t, changed)
+	require.Equal(t, nostr.Tags{
+		{"r", "wss://read.com", "read"},
+		{"r", "wss://new.com", "write"},
+	}, evt.Tags)
+}
+
+func TestModifyListRequiresEOSE(t *testing.T) {
+	sk := nostr.GeneratePrivateKey()
+	pk, _ := nostr.GetPublicKey(sk)
+	signer := keyring.ManualSigner{
+		ManualGetPublicKey: func(ctx context.Context) string { return pk },
+		ManualSignEvent:    func(ctx context.Context, evt *nostr.Event) error { return evt.Sign(sk) },
+	}
+	const alice = "3bf0c63fcb93463407af97a5e5ee64fa883d107ef9e558472c4eb9aaaefa459d"
+
+	setup := func(indexer *mockRelay, outbox ...*mockRelay) *System {
+		sys := NewSystem(
+			WithRelayListRelays([]string{indexer.URL}),
+			WithFollowListRelays([]string{indexer.URL}),
+			WithMetadataRelays([]string{indexer.URL}),
+			WithFallbackRelays([]string{indexer.URL}),
+		)
+
+		rl := nostr.Event{Kind: 10002, PubKey: pk, CreatedAt: nostr.Now()}
+		for _, mr := range outbox {
+			rl.Tags = append(rl.Tags, nostr.Tag{"r", mr.URL})
+		}
+		rl.Sign(sk)
+		sys.Store.SaveEvent(context.Background(), &rl)
+
+		return sys
+	}
+
+	// a relay that never sends an EOSE doesn't count as reached
+	silent := newMockRelay(t, "")
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	_, _, err := setup(silent, silent).Follow(ctx, signer, Follow{Pubkey: alice})
+	require.ErrorContains(t, err, "couldn't reach any relay")
+	require.Empty(t, silent.Published())
+
+	// one relay saying it doesn't have the list isn't enough, and neither is one that requires auth
+	eose := newMockRelay(t, "EOSE")
+	closed := newMockRelay(t, "CLOSED")
+	ctx, cancel = context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	_, _, err = setup(silent, eose, closed).Follow(ctx, signer, Follow{Pubkey: alice})
+	require.ErrorContains(t, err, "only 1 relays (0 indexers)")
+	require.Empty(t, eose.Published())
+	require.Empty(t, closed.Published())
+
+	// but two of them, one being an indexer, are
+	indexer := newMockRelay(t, "EOSE")
+	fl, results, err := setup(indexer, eose).Follow(context.Background(), signer, Follow{Pubkey: alice})
+	require.NoError(t, err)
+	require.Equal(t, []Follow{{Pubkey: alice}}, fl.Items)
+	require.NotEmpty(t, results)
+	require.Len(t, eose.Published(), 1)
+	require.Len(t, indexer.Published(), 1)
+}
+
+// mockRelay is a relay that answers every REQ in the same way: with an EOSE, with a CLOSED or never, and that
+// accepts all the events published to it.
+type mockRelay struct {
+	URL string
+
+	mu        sync.Mutex
+	published []nostr.Event
+}
+
+func newMockRelay(t *testing.T, answer string) *mockRelay {
+	mr := &mockRelay{}
+	server := httptest.NewServer(&websocket.Server{
+		// nostr clients send no origin
+		Handshake: func(*websocket.Config, *http.Request) error { return nil },
+		Handler: func(conn *websocket.Conn) {
+			for {
+				var msg []json.RawMessage
+				if err := websocket.JSON.Receive(conn, &msg); err != nil {
+					return
+				}
+				if len(msg) < 2 {
+					continue
+				}
+
+				var typ string
+				json.Unmarshal(msg[0], &typ)
+				switch typ {
+				case "REQ":
+					var id string
+					json.Unmarshal(msg[1], &id)
+					switch answer {
+					case "EOSE":
+						websocket.JSON.Send(conn, []any{"EOSE", id})
+					case "CLOSED":
+						websocket.JSON.Send(conn, []any{"CLOSED", id, "auth-required: only for members"})
+					}
+				case "EVENT":
+					var evt nostr.Event
+					if err := json.Unmarshal(msg[1], &evt); err != nil {
+						continue
+					}
+					mr.mu.Lock()
+					mr.published = append(mr.published, evt)
+					mr.mu.Unlock()
+					websocket.JSON.Send(conn, []any{"OK", evt.ID, true, ""})
+				}
+			}
+		},
+	})
+	t.Cleanup(server.Close)
+
+	mr.URL = "ws" + strings.TrimPrefix(server.URL, "http")
+	return mr
+}
+
+func (mr *mockRelay) Published() []nostr.Event {
+	mr.mu.Lock()
+	defer mr.mu.Unlock()
+	return slices.Clone(mr.published)
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/nbd-wtf/go-nostr"
 	"github.com/nbd-wtf/go-nostr/nip19"
 	"github.com/nbd-wtf/nostr-sdk/hints"
+	"github.com/nbd-wtf/nostr-sdk/keyring"
 )
 
 type ProfileMetadata struct {
@@ -18,14 +19,122 @@ type ProfileMetadata struct {
 	Event  *nostr.Event `json:"-"` // may be empty if a profile metadata event wasn't found
 
 	// every one of these may be empty
-	Name        string `json:"name,omitempty"`
-	DisplayName string `json:"display_name,omitempty"`
-	About       string `json:"about,omitempty"`
-	Website     string `json:"website,omitempty"`
-	Picture     string `json:"picture,omitempty"`
-	Banner      string `json:"banner,omitempty"`
-	NIP05       string `json:"nip05,omitempty"`
-	LUD16       string `json:"lud16,omitempty"`
+	Name        string    `json:"name,omitempty"`
+	DisplayName string    `json:"display_name,omitempty"`
+	About       string    `json:"about,omitempty"`
+	Website     string    `json:"website,omitempty"`
+	Picture     string    `json:"picture,omitempty"`
+	Banner      string    `json:"banner,omitempty"`
+	NIP05       string    `json:"nip05,omitempty"`
+	LUD16       string    `json:"lud16,omitempty"`
+	LUD06       string    `json:"lud06,omitempty"`
+	Pronouns    string    `json:"pronouns,omitempty"`
+	Bot         bool      `json:"bot,omitempty"`
+	Birthday    *Birthday `json:"birthday,omitempty"`
+
+	// all the other fields found in the metadata json, including the ones above if they had an unexpected type
+	// or an empty value, so they can be written back when the profile is re-serialized
+	Extra map[string]json.RawMessage `json:"-"`
+
+	// the result of the last VerifyNIP05 call for this profile, if any
+	NIP05Verification *NIP05Verification `json:"-"`
+}
+
+// Birthday is the NIP-24 birthday object, every field is optional.
+type Birthday struct {
+	Year  int `json:"year,omitempty"`
+	Month int `json:"month,omitempty"`
+	Day   int `json:"day,omitempty"`
+}
+
+// UnmarshalJSON parses the known fields of a kind 0 content and keeps everything else in Extra.
+// fields with an unexpected type don't cause an error, they are just kept in Extra too, and so are the fields
+// that are explicitly set to an empty value (like "bot": false), otherwise they would be dropped when writing.
+func (p *ProfileMetadata) UnmarshalJSON(data []byte) error {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	for key, value := range raw {
+		var err error
+		switch key {
+		case "name":
+			err = json.Unmarshal(value, &p.Name)
+		case "display_name":
+			err = json.Unmarshal(value, &p.DisplayName)
+		case "about":
+			err = json.Unmarshal(value, &p.About)
+		case "website":
+			err = json.Unmarshal(value, &p.Website)
+		case "picture":
+			err = json.Unmarshal(value, &p.Picture)
+		case "banner":
+			err = json.Unmarshal(value, &p.Banner)
+		case "nip05":
+			err = json.Unmarshal(value, &p.NIP05)
+		case "lud16":
+			err = json.Unmarshal(value, &p.LUD16)
+		case "lud06":
+			err = json.Unmarshal(value, &p.LUD06)
+		case "pronouns":
+			err = json.Unmarshal(value, &p.Pronouns)
+		case "bot":
+			err = json.Unmarshal(value, &p.Bot)
+		case "birthday":
+			p.Birthday = &Birthday{}
+			if err = json.Unmarshal(value, p.Birthday); err != nil || isEmptyJSON(value) {
+				p.Birthday = nil
+			}
+		default:
+			err = fmt.Errorf("unknown field")
+		}
+
+		if err != nil || isEmptyJSON(value) {
+			if p.Extra == nil {
+				p.Extra = make(map[string]json.RawMessage)
+			}
+			p.Extra[key] = value
+		}
+	}
+
+	// deprecated, but still written by some clients
+	if p.DisplayName == "" {
+		if value, ok := p.Extra["displayName"]; ok {
+			json.Unmarshal(value, &p.DisplayName)
+		}
+	}
+
+	return nil
+}
+
+// MarshalJSON writes the known fields along with everything in Extra, the known fields taking precedence.
+func (p ProfileMetadata) MarshalJSON() ([]byte, error) {
+	type known ProfileMetadata // without the methods, so we don't recurse
+	data, err := json.Marshal(known(p))
+	if err != nil || len(p.Extra) == 0 {
+		return data, err
+	}
+
+	var merged map[string]json.RawMessage
+	if err := json.Unmarshal(data, &merged); err != nil {
+		return nil, err
+	}
+	for key, value := range p.Extra {
+		if _, ok := merged[key]; !ok {
+			merged[key] = value
+		}
+	}
+	return json.Marshal(merged)
+}
+
+// isEmptyJSON tells if a raw json value is one that would be omitted by omitempty.
+func isEmptyJSON(value json.RawMessage) bool {
+	switch strings.TrimSpace(string(value)) {
+	case `""`, "false", "0", "null":
+		return true
+	}
+	return false
 }
 
 func (p ProfileMetadata) Npub() string {
@@ -80,7 +189,8 @@ func (sys System) FetchProfileFromInput(ctx context.Context, nip19OrNip05Code st
 // or, failing these, from the target user's defined outbox relays -- then caches the result.
 func (sys *System) FetchProfileMetadata(ctx context.Context, pubkey string) (pm ProfileMetadata) {
 	if v, ok := sys.MetadataCache.Get(pubkey); ok {
-		return v
+		sys.revalidate(0, pubkey, v.Event, sys.profileRefreshed)
+		return sys.withCachedNIP05Verification(v)
 	}
 
 	res, _ := sys.StoreRelay.QuerySync(ctx, nostr.Filter{Kinds: []int{0}, Authors: []string{pubkey}})
@@ -89,21 +199,28 @@ func (sys *System) FetchProfileMetadata(ctx context.Context, pubkey string) (pm
 			m.PubKey = pubkey
 			m.Event = res[0]
 			sys.MetadataCache.SetWithTTL(pubkey, m, time.Hour*6)
-			return m
+			sys.indexProfile(m)
+			sys.revalidate(0, pubkey, m.Event, sys.profileRefreshed)
+			return sys.withCachedNIP05Verification(m)
 		}
 	}
 
 	pm.PubKey = pubkey
 
+	sys.freshness.mark(0, pubkey)
 	thunk0 := sys.replaceableLoaders[0].Load(ctx, pubkey)
 	evt, err := thunk0()
 	if err == nil {
-		pm, _ = ParseMetadata(evt)
+		var perr error
+		pm, perr = ParseMetadata(evt)
 
 		// save on store even if the metadata json is malformed
 		if sys.StoreRelay != nil && pm.Event != nil {
 			sys.StoreRelay.Publish(ctx, *pm.Event)
 		}
+		if perr == nil {
+			sys.indexProfile(pm)
+		}
 	}
 
 	// save on cache even if the metadata isn't found (unless the context was canceled)
@@ -111,7 +228,79 @@ func (sys *System) FetchProfileMetadata(ctx context.Context, pubkey string) (pm
 		sys.MetadataCache.SetWithTTL(pubkey, pm, time.Hour*6)
 	}
 
-	return pm
+	return sys.withCachedNIP05Verification(pm)
+}
+
+// profileRefreshed is called when a newer profile is found by a background refresh.
+func (sys *System) profileRefreshed(evt *nostr.Event) {
+	pm, err := ParseMetadata(evt)
+	if err != nil {
+		return
+	}
+	sys.MetadataCache.SetWithTTL(pm.PubKey, pm, time.Hour*6)
+	sys.indexProfile(pm)
+	sys.notifyRefresh(RefreshedValue{PubKey: pm.PubKey, Kind: 0, Event: evt, Value: sys.withCachedNIP05Verification(pm)})
+}
+
+// UpdateProfile fetches the newest profile metadata for the signer's pubkey directly from relays, applies the
+// given change to it, then signs and publishes the result to the user's outbox relays and to the MetadataRelays,
+// also updating the MetadataCache, the local store and the local search index.
+//
+// fields this library doesn't know about and tags in the existing event are preserved. like ModifyList, this
+// refuses to proceed when no relay has the profile we know about or, if we don't know one, when not enough relays
+// said they don't have any, so an outdated or an empty profile is never published over the real one.
+func (sys *System) UpdateProfile(
+	ctx context.Context,
+	signer keyring.Signer,
+	change func(*ProfileMetadata),
+) (ProfileMetadata, []PublishResult, error) {
+	pubkey := signer.GetPublicKey(ctx)
+	latest := sys.fetchLatestReplaceable(ctx, pubkey, 0)
+
+	// what we knew about this profile before
+	known := latest.Local
+	if cached, ok := sys.MetadataCache.Get(pubkey); ok && cached.Event != nil &&
+		(known == nil || cached.Event.CreatedAt > known.CreatedAt) {
+		known = cached.Event
+	}
+
+	if err := latest.checkSafeToReplace(known, len(sys.indexerRelays(0)) > 0); err != nil {
+		return ProfileMetadata{PubKey: pubkey}, nil, fmt.Errorf("can't update profile: %w", err)
+	}
+
+	pm := ProfileMetadata{PubKey: pubkey}
+	tags := nostr.Tags{}
+	if latest.Event != nil {
+		var err error
+		if pm, err = ParseMetadata(latest.Event); err != nil {
+			// if we go on we'll overwrite whatever is in there
+			return pm, nil, fmt.Errorf("current profile can't be parsed: %w", err)
+		}
+		tags = latest.Event.Tags
+	}
+
+	change(&pm)
+
+	content, err := json.Marshal(pm)
+	if err != nil {
+		return pm, nil, fmt.Errorf("failed to encode profile: %w", err)
+	}
+
+	evt, results, err := sys.publish(ctx, signer, nostr.Event{
+		Kind:      0,
+		CreatedAt: nextTimestamp(latest.Event),
+		Tags:      tags,
+		Content:   string(content),
+	}, sys.indexerRelays(0))
+	if err != nil {
+		return pm, results, err
+	}
+
+	pm.Event = &evt
+	sys.MetadataCache.SetWithTTL(pubkey, pm, time.Hour*6)
+	sys.indexProfile(pm)
+
+	return pm, results, nil
 }
 
 // FetchUserEvents fetches events from each users' outbox relays, grouping queries when possible.
@@ -0,0 +1,122 @@
+package sdk
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/nbd-wtf/go-nostr"
+	cache_memory "github.com/nbd-wtf/nostr-sdk/cache/memory"
+	"github.com/nbd-wtf/nostr-sdk/keyring"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseMetadata(t *testing.T) {
+	content := `{"name":"fiatjaf","about":"~","lud06":"lnurl1xyz","lud16":"fiatjaf@zbd.gg","bot":true,"pronouns":"he/him","birthday":{"month":3,"day":14},"website":123,"displayName":"Fiatjaf","custom":{"a":[1,2]}}`
+
+	pm, err := ParseMetadata(&nostr.Event{Kind: 0, PubKey: "abc", Content: content})
+	require.NoError(t, err)
+	require.Equal(t, "abc", pm.PubKey)
+	require.Equal(t, "fiatjaf", pm.Name)
+	require.Equal(t, "Fiatjaf", pm.DisplayName)
+	require.Equal(t, "lnurl1xyz", pm.LUD06)
+	require.Equal(t, "fiatjaf@zbd.gg", pm.LUD16)
+	require.True(t, pm.Bot)
+	require.Equal(t, "he/him", pm.Pronouns)
+	require.Equal(t, &Birthday{Month: 3, Day: 14}, pm.Birthday)
+	require.Empty(t, pm.Website)
+	require.Len(t, pm.Extra, 3)
+
+	// everything is written back
+	out, err := json.Marshal(pm)
+	require.NoError(t, err)
+	var original, reserialized map[string]any
+	require.NoError(t, json.Unmarshal([]byte(content), &original))
+	require.NoError(t, json.Unmarshal(out, &reserialized))
+	original["display_name"] = "Fiatjaf"
+	require.Equal(t, original, reserialized)
+
+	// known fields take precedence over extra fields when they are modified
+	pm.Website = "https://fiatjaf.com"
+	out, err = json.Marshal(pm)
+	require.NoError(t, err)
+	require.Contains(t, string(out), `"website":"https://fiatjaf.com"`)
+	require.NotContains(t, string(out), `"website":123`)
+
+	_, err = ParseMetadata(&nostr.Event{Kind: 0, Content: `["not", "an", "object"]`})
+	require.Error(t, err)
+
+	// explicitly empty values are kept too
+	content = `{"name":"","bot":false,"about":null,"birthday":null}`
+	pm, err = ParseMetadata(&nostr.Event{Kind: 0, Content: content})
+	require.NoError(t, err)
+	require.Nil(t, pm.Birthday)
+	out, err = json.Marshal(pm)
+	require.NoError(t, err)
+	require.JSONEq(t, content, string(out))
+}
+
+func TestUserSearchResultJSON(t *testing.T) {
+	r := UserSearchResult{ProfileMetadata: ProfileMetadata{Name: "x"}, Relays: []string{"wss://relay.com"}, Score: 2}
+	out, err := json.Marshal(r)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"name":"x","Relays":["wss://relay.com"],"Score":2}`, string(out))
+
+	var back UserSearchResult
+	require.NoError(t, json.Unmarshal(out, &back))
+	require.Equal(t, r, back)
+}
+
+func TestUpdateProfileRefusesToOverwrite(t *testing.T) {
+	sk := nostr.GeneratePrivateKey()
+	pk, _ := nostr.GetPublicKey(sk)
+	signer := keyring.ManualSigner{
+		ManualGetPublicKey: func(ctx context.Context) string { return pk },
+		ManualSignEvent:    func(ctx context.Context, evt *nostr.Event) error { return evt.Sign(sk) },
+	}
+
+	// neither of these relays has a profile for us
+	outbox := newMockRelay(t, "EOSE")
+	indexer := newMockRelay(t, "EOSE")
+	setup := func() *System {
+		sys := NewSystem(
+			WithRelayListRelays([]string{indexer.URL}),
+			WithFollowListRelays([]string{indexer.URL}),
+			WithMetadataRelays([]string{indexer.URL}),
+			WithFallbackRelays([]string{indexer.URL}),
+		)
+		rl := nostr.Event{Kind: 10002, PubKey: pk, CreatedAt: nostr.Now(), Tags: nostr.Tags{{"r", outbox.URL}}}
+		rl.Sign(sk)
+		sys.Store.SaveEvent(context.Background(), &rl)
+		return sys
+	}
+	rename := func(pm *ProfileMetadata) { pm.Name = "renamed" }
+
+	old := nostr.Event{Kind: 0, PubKey: pk, CreatedAt: nostr.Now() - 100, Content: `{"name":"old","about":"stuff"}`}
+	old.Sign(sk)
+
+	// we have it stored
+	sys := setup()
+	sys.Store.SaveEvent(context.Background(), &old)
+	_, _, err := sys.UpdateProfile(context.Background(), signer, rename)
+	require.ErrorContains(t, err, "no relay has the version")
+
+	// we have it cached
+	sys = setup()
+	pm, _ := ParseMetadata(&old)
+	sys.MetadataCache.SetWithTTL(pk, pm, time.Hour)
+	sys.MetadataCache.(*cache_memory.RistrettoCache[ProfileMetadata]).Cache.Wait()
+	_, _, err = sys.UpdateProfile(context.Background(), signer, rename)
+	require.ErrorContains(t, err, "no relay has the version")
+
+	require.Empty(t, outbox.Published())
+	require.Empty(t, indexer.Published())
+
+	// we don't know any, so a new one is created
+	pm, _, err = setup().UpdateProfile(context.Background(), signer, rename)
+	require.NoError(t, err)
+	require.Equal(t, `{"name":"renamed"}`, pm.Event.Content)
+	require.Len(t, outbox.Published(), 1)
+	require.Len(t, indexer.Published(), 1)
+}
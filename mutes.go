@@ -1,10 +1,158 @@
 package sdk
 
-import "context"
+import (
+	"context"
+	"strings"
+	"time"
 
-type MuteList = GenericList[Follow]
+	"github.com/nbd-wtf/go-nostr"
+	"github.com/nbd-wtf/nostr-sdk/cache"
+	"github.com/nbd-wtf/nostr-sdk/keyring"
+)
+
+// MuteList is a NIP-51 mute list (kind 10000), it can contain muted pubkeys, hashtags, words and threads.
+type MuteList struct {
+	GenericList[Mute]
+}
+
+// Mute is a single entry in a MuteList.
+type Mute struct {
+	Tag    string // one of "p", "t", "word" or "e"
+	Target string // the pubkey, hashtag (lowercase and without the "#"), word (lowercase) or thread root id
+}
+
+func (m Mute) Value() string { return m.Tag + ":" + m.Target }
+
+func (m Mute) ToTag() nostr.Tag { return nostr.Tag{m.Tag, m.Target} }
 
 func (sys *System) FetchMuteList(ctx context.Context, pubkey string) MuteList {
-	ml, _ := fetchGenericList[Follow](sys, ctx, pubkey, 10000, parseFollow, nil, false)
-	return ml
+	ml, _ := fetchGenericList[Mute](sys, ctx, pubkey, 10000, parseMute, sys.muteListCache(), false)
+	return MuteList{ml}
+}
+
+// UpdateMuteList fetches the signer's latest mute list, applies the given change to it and publishes it.
+// See ModifyList for the safety checks performed.
+func (sys *System) UpdateMuteList(
+	ctx context.Context,
+	signer keyring.Signer,
+	change func(*MuteList),
+) (MuteList, []PublishResult, error) {
+	ml, results, err := ModifyList(ctx, sys, signer, 10000, func(l *GenericList[Mute]) {
+		ml := MuteList{*l}
+		change(&ml)
+		*l = ml.GenericList
+	})
+	return MuteList{ml}, results, err
+}
+
+// IsMuted tells if the given event should be hidden according to this list, i.e. if it was authored by a
+// muted pubkey, if it has a muted hashtag, if it contains a muted word or if it belongs to a muted thread.
+func (ml MuteList) IsMuted(evt *nostr.Event) bool {
+	var content string
+	for _, m := range ml.Items {
+		switch m.Tag {
+		case "p":
+			if evt.PubKey == m.Target {
+				return true
+			}
+		case "e":
+			if evt.ID == m.Target {
+				return true
+			}
+			for _, tag := range evt.Tags {
+				if len(tag) >= 2 && tag[0] == "e" && tag[1] == m.Target {
+					return true
+				}
+			}
+		case "t":
+			for _, tag := range evt.Tags {
+				if len(tag) >= 2 && tag[0] == "t" && strings.ToLower(tag[1]) == m.Target {
+					return true
+				}
+			}
+		case "word":
+			if content == "" {
+				content = strings.ToLower(evt.Content)
+			}
+			if strings.Contains(content, m.Target) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// RemoveMuted removes from the results of FetchUserEvents all the events that are muted by this list.
+func (ml MuteList) RemoveMuted(results map[string][]*nostr.Event) {
+	for pubkey, events := range results {
+		kept := events[:0]
+		for _, evt := range events {
+			if !ml.IsMuted(evt) {
+				kept = append(kept, evt)
+			}
+		}
+		if len(kept) == 0 {
+			delete(results, pubkey)
+		} else {
+			results[pubkey] = kept
+		}
+	}
+}
+
+func parseMute(tag nostr.Tag) (m Mute, ok bool) {
+	if len(tag) < 2 {
+		return m, false
+	}
+
+	switch tag[0] {
+	case "p":
+		if !nostr.IsValidPublicKey(tag[1]) {
+			return m, false
+		}
+		m.Target = tag[1]
+	case "e":
+		if !nostr.IsValid32ByteHex(tag[1]) {
+			return m, false
+		}
+		m.Target = tag[1]
+	case "t":
+		m.Target = parseHashtag(tag[1])
+	case "word":
+		m.Target = strings.ToLower(strings.TrimSpace(tag[1]))
+	default:
+		return m, false
+	}
+
+	if m.Target == "" {
+		return m, false
+	}
+
+	m.Tag = tag[0]
+	return m, true
+}
+
+// muteListCache lets the MuteListCache be used where a cache of GenericList[Mute] is expected.
+type muteListCache struct {
+	cache.Cache32[MuteList]
+}
+
+func (sys *System) muteListCache() cache.Cache32[GenericList[Mute]] {
+	if sys.MuteListCache == nil {
+		return nil
+	}
+	return muteListCache{sys.MuteListCache}
+}
+
+func (c muteListCache) Get(k string) (GenericList[Mute], bool) {
+	ml, ok := c.Cache32.Get(k)
+	return ml.GenericList, ok
+}
+
+func (c muteListCache) Set(k string, v GenericList[Mute]) bool {
+	return c.Cache32.Set(k, MuteList{v})
+}
+
+func (c muteListCache) SetWithTTL(k string, v GenericList[Mute], d time.Duration) bool {
+	return c.Cache32.SetWithTTL(k, MuteList{v}, d)
 }
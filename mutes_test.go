@@ -0,0 +1,50 @@
+package sdk
+
+import (
+	"testing"
+
+	"github.com/nbd-wtf/go-nostr"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMuteList(t *testing.T) {
+	const muted = "3bf0c63fcb93463407af97a5e5ee64fa883d107ef9e558472c4eb9aaaefa459d"
+	const other = "a84c5de86efc2ec2cff7bad077c4171e09146b633b7ad117fffe088d9579ac33"
+	const thread = "31d7c2875b5fc8e6f9c8f9dc1f84de1b6b91d1947ea4c59225e55c325d330fa8"
+
+	evt := &nostr.Event{
+		Kind: 10000,
+		Tags: nostr.Tags{
+			{"p", muted},
+			{"p", "invalid"},
+			{"t", "#Bitcoin"},
+			{"word", "GM"},
+			{"e", thread},
+			{"word", ""},
+			{"r", "wss://relay.com"},
+		},
+	}
+
+	ml := MuteList{GenericList[Mute]{Items: parseItemsFromEventTags(evt, parseMute)}}
+	require.Equal(t, []Mute{
+		{Tag: "p", Target: muted},
+		{Tag: "t", Target: "bitcoin"},
+		{Tag: "word", Target: "gm"},
+		{Tag: "e", Target: thread},
+	}, ml.Items)
+
+	require.True(t, ml.IsMuted(&nostr.Event{PubKey: muted, Content: "hello"}))
+	require.True(t, ml.IsMuted(&nostr.Event{PubKey: other, Content: "hello", Tags: nostr.Tags{{"t", "bitcoin"}}}))
+	require.True(t, ml.IsMuted(&nostr.Event{PubKey: other, Content: "gm, friends"}))
+	require.True(t, ml.IsMuted(&nostr.Event{PubKey: other, Content: "hi", Tags: nostr.Tags{{"e", thread, "", "root"}}}))
+	require.False(t, ml.IsMuted(&nostr.Event{PubKey: other, Content: "hello", Tags: nostr.Tags{{"t", "nostr"}}}))
+
+	results := map[string][]*nostr.Event{
+		muted: {{PubKey: muted}},
+		other: {{PubKey: other, Content: "GM"}, {PubKey: other, Content: "good morning"}},
+	}
+	ml.RemoveMuted(results)
+	require.Len(t, results, 1)
+	require.Len(t, results[other], 1)
+	require.Equal(t, "good morning", results[other][0].Content)
+}
@@ -0,0 +1,140 @@
+package sdk
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/nbd-wtf/go-nostr"
+	"github.com/nbd-wtf/go-nostr/nip05"
+	"github.com/nbd-wtf/nostr-sdk/hints"
+)
+
+type NIP05Status int
+
+const (
+	NIP05Unchecked   NIP05Status = iota // no verification was attempted yet
+	NIP05Verified                       // the domain says this identifier belongs to this pubkey
+	NIP05Mismatch                       // the domain doesn't know this identifier, or assigns it to someone else
+	NIP05Unreachable                    // we couldn't get a valid nostr.json from the domain
+)
+
+func (s NIP05Status) String() string {
+	switch s {
+	case NIP05Verified:
+		return "verified"
+	case NIP05Mismatch:
+		return "mismatch"
+	case NIP05Unreachable:
+		return "unreachable"
+	default:
+		return "unchecked"
+	}
+}
+
+// NIP05Verification is the result of checking a profile's NIP-05 identifier against its domain.
+type NIP05Verification struct {
+	Identifier string
+	Status     NIP05Status
+	CheckedAt  nostr.Timestamp
+}
+
+// VerifyNIP05 checks the NIP-05 identifier claimed by the given profile against the domain's nostr.json using
+// the System's HTTPClient. Results are cached, with failures being retried sooner than successes, and are
+// attached to the profiles returned by subsequent FetchProfileMetadata calls.
+//
+// profiles without a NIP-05 identifier get an NIP05Unchecked result.
+func (sys *System) VerifyNIP05(ctx context.Context, pm ProfileMetadata) NIP05Verification {
+	if pm.NIP05 == "" {
+		return NIP05Verification{}
+	}
+
+	if v, ok := sys.NIP05Cache.Get(pm.PubKey); ok && v.Identifier == pm.NIP05 {
+		return v
+	}
+
+	v := NIP05Verification{
+		Identifier: pm.NIP05,
+		CheckedAt:  nostr.Now(),
+	}
+
+	if !nip05.IsValidIdentifier(pm.NIP05) {
+		v.Status = NIP05Mismatch
+		sys.NIP05Cache.SetWithTTL(pm.PubKey, v, time.Hour*6)
+		return v
+	}
+
+	resp, name, err := sys.fetchNIP05(ctx, pm.NIP05)
+	if err != nil {
+		if ctx.Err() != nil {
+			// we don't know anything, so don't cache this
+			return NIP05Verification{Identifier: pm.NIP05}
+		}
+		v.Status = NIP05Unreachable
+		sys.NIP05Cache.SetWithTTL(pm.PubKey, v, time.Minute*30)
+	} else if pubkey, ok := resp.Names[name]; !ok || pubkey != pm.PubKey {
+		v.Status = NIP05Mismatch
+		sys.NIP05Cache.SetWithTTL(pm.PubKey, v, time.Hour*6)
+	} else {
+		v.Status = NIP05Verified
+		sys.NIP05Cache.SetWithTTL(pm.PubKey, v, time.Hour*24)
+
+		for _, r := range resp.Relays[pm.PubKey] {
+			nm := nostr.NormalizeURL(r)
+			if !IsVirtualRelay(nm) {
+				sys.Hints.Save(pm.PubKey, nm, hints.LastInNIP05, v.CheckedAt)
+			}
+		}
+	}
+
+	return v
+}
+
+// fetchNIP05 is like nip05.Fetch, but uses our own HTTP client.
+func (sys *System) fetchNIP05(ctx context.Context, identifier string) (resp nip05.WellKnownResponse, name string, err error) {
+	name, domain, err := nip05.ParseIdentifier(identifier)
+	if err != nil {
+		return resp, name, fmt.Errorf("failed to parse '%s': %w", identifier, err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET",
+		fmt.Sprintf("https://%s/.well-known/nostr.json?name=%s", domain, name), nil)
+	if err != nil {
+		return resp, name, fmt.Errorf("failed to create a request: %w", err)
+	}
+
+	res, err := sys.HTTPClient.Do(req)
+	if err != nil {
+		return resp, name, fmt.Errorf("request failed: %w", err)
+	}
+	defer res.Body.Close()
+
+	// redirects are not allowed by NIP-05, so anything other than this is a failure
+	if res.StatusCode != http.StatusOK {
+		return resp, name, fmt.Errorf("got status %d", res.StatusCode)
+	}
+
+	if err := json.NewDecoder(res.Body).Decode(&resp); err != nil {
+		return resp, name, fmt.Errorf("failed to decode json response: %w", err)
+	}
+
+	// names are supposed to be lowercase, but people write them in all sorts of ways
+	if _, ok := resp.Names[name]; !ok {
+		name = strings.ToLower(name)
+	}
+
+	return resp, name, nil
+}
+
+// withCachedNIP05Verification attaches a previous verification result to the profile, if we have one.
+func (sys *System) withCachedNIP05Verification(pm ProfileMetadata) ProfileMetadata {
+	if pm.NIP05 != "" {
+		if v, ok := sys.NIP05Cache.Get(pm.PubKey); ok && v.Identifier == pm.NIP05 {
+			pm.NIP05Verification = &v
+		}
+	}
+	return pm
+}
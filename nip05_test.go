@@ -0,0 +1,96 @@
+package sdk
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+
+	cache_memory "github.com/nbd-wtf/nostr-sdk/cache/memory"
+	"github.com/stretchr/testify/require"
+)
+
+// redirectTransport sends every request to the test server regardless of the domain
+type redirectTransport struct{ target *url.URL }
+
+func (rt redirectTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req.URL.Scheme = rt.target.Scheme
+	req.URL.Host = rt.target.Host
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestVerifyNIP05(t *testing.T) {
+	const pubkey = "3bf0c63fcb93463407af97a5e5ee64fa883d107ef9e558472c4eb9aaaefa459d"
+	const other = "a84c5de86efc2ec2cff7bad077c4171e09146b633b7ad117fffe088d9579ac33"
+
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		switch r.Host {
+		case "good.com":
+			w.Write([]byte(`{"names":{"fiatjaf":"` + pubkey + `","_":"` + other + `"},"relays":{"` + pubkey + `":["wss://relay.good.com"]}}`))
+		case "broken.com":
+			w.WriteHeader(http.StatusInternalServerError)
+		case "moved.com":
+			http.Redirect(w, r, "https://good.com/.well-known/nostr.json", http.StatusFound)
+		}
+	}))
+	defer server.Close()
+
+	target, _ := url.Parse(server.URL)
+	sys := NewSystem(WithHTTPClient(&http.Client{
+		Transport: redirectTransport{target},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}))
+	ctx := context.Background()
+
+	// memory cache writes are asynchronous
+	wait := func() {
+		sys.NIP05Cache.(*cache_memory.RistrettoCache[NIP05Verification]).Cache.Wait()
+		sys.MetadataCache.(*cache_memory.RistrettoCache[ProfileMetadata]).Cache.Wait()
+	}
+
+	for _, tc := range []struct {
+		nip05  string
+		status NIP05Status
+	}{
+		{"fiatjaf@good.com", NIP05Verified},
+		{"FiatJaf@good.com", NIP05Verified},
+		{"good.com", NIP05Mismatch},
+		{"someone@good.com", NIP05Mismatch},
+		{"fiatjaf@broken.com", NIP05Unreachable},
+		{"fiatjaf@moved.com", NIP05Unreachable},
+		{"not an identifier", NIP05Mismatch},
+	} {
+		sys.NIP05Cache.Delete(pubkey)
+		wait()
+		v := sys.VerifyNIP05(ctx, ProfileMetadata{PubKey: pubkey, NIP05: tc.nip05})
+		require.Equal(t, tc.status, v.Status, tc.nip05)
+		require.Equal(t, tc.nip05, v.Identifier)
+	}
+
+	// results are cached and attached to profiles
+	sys.NIP05Cache.Delete(pubkey)
+	wait()
+	pm := ProfileMetadata{PubKey: pubkey, NIP05: "fiatjaf@good.com"}
+	sys.VerifyNIP05(ctx, pm)
+	wait()
+	before := requests.Load()
+	require.Equal(t, NIP05Verified, sys.VerifyNIP05(ctx, pm).Status)
+	require.Equal(t, before, requests.Load())
+
+	sys.MetadataCache.Set(pubkey, pm)
+	wait()
+	cached := sys.FetchProfileMetadata(ctx, pubkey)
+	require.NotNil(t, cached.NIP05Verification)
+	require.Equal(t, NIP05Verified, cached.NIP05Verification.Status)
+
+	// but not if the identifier changes
+	require.Equal(t, NIP05Mismatch, sys.VerifyNIP05(ctx, ProfileMetadata{PubKey: pubkey, NIP05: "other@good.com"}).Status)
+	wait()
+	require.Nil(t, sys.FetchProfileMetadata(ctx, pubkey).NIP05Verification)
+}
@@ -0,0 +1,408 @@
+package sdk
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/nbd-wtf/go-nostr"
+	"github.com/nbd-wtf/nostr-sdk/keyring"
+)
+
+type (
+	PinnedNotesList  = GenericList[EventRef]
+	BookmarkList     = GenericList[Bookmark]
+	CommunityList    = GenericList[AddressRef]
+	PublicChatList   = GenericList[EventRef]
+	BlockedRelayList = GenericList[RelayURL]
+	SearchRelayList  = GenericList[RelayURL]
+	InterestList     = GenericList[Interest]
+	EmojiList        = GenericList[Emoji]
+)
+
+// EventRef is an "e" tag in a list.
+type EventRef struct {
+	ID    string
+	Relay string
+}
+
+func (e EventRef) Value() string { return e.ID }
+
+func (e EventRef) ToTag() nostr.Tag { return trimTag(nostr.Tag{"e", e.ID, e.Relay}) }
+
+// AddressRef is an "a" tag in a list, pointing to an addressable event.
+type AddressRef struct {
+	Kind       int
+	PubKey     string
+	Identifier string
+	Relay      string
+}
+
+func (a AddressRef) Value() string { return addressableKey(a.Kind, a.PubKey, a.Identifier) }
+
+func (a AddressRef) ToTag() nostr.Tag { return trimTag(nostr.Tag{"a", a.Value(), a.Relay}) }
+
+func (a AddressRef) Pointer() nostr.EntityPointer {
+	ep := nostr.EntityPointer{Kind: a.Kind, PublicKey: a.PubKey, Identifier: a.Identifier}
+	if a.Relay != "" {
+		ep.Relays = []string{a.Relay}
+	}
+	return ep
+}
+
+// RelayURL is a "relay" tag in a list.
+type RelayURL string
+
+func (r RelayURL) Value() string { return string(r) }
+
+func (r RelayURL) ToTag() nostr.Tag { return nostr.Tag{"relay", string(r)} }
+
+// Bookmark is an item in a bookmark list, only one of its fields will be set.
+type Bookmark struct {
+	Event   *EventRef
+	Address *AddressRef
+	Hashtag string
+	URL     string
+}
+
+func (b Bookmark) Value() string {
+	switch {
+	case b.Event != nil:
+		return "e:" + b.Event.Value()
+	case b.Address != nil:
+		return "a:" + b.Address.Value()
+	case b.Hashtag != "":
+		return "t:" + b.Hashtag
+	default:
+		return "r:" + b.URL
+	}
+}
+
+func (b Bookmark) ToTag() nostr.Tag {
+	switch {
+	case b.Event != nil:
+		return b.Event.ToTag()
+	case b.Address != nil:
+		return b.Address.ToTag()
+	case b.Hashtag != "":
+		return nostr.Tag{"t", b.Hashtag}
+	default:
+		return nostr.Tag{"r", b.URL}
+	}
+}
+
+// Interest is an item in an interest list, either a hashtag or a pointer to an interest set (kind 30015).
+type Interest struct {
+	Hashtag string
+	Set     *AddressRef
+}
+
+func (i Interest) Value() string {
+	if i.Set != nil {
+		return "a:" + i.Set.Value()
+	}
+	return "t:" + i.Hashtag
+}
+
+func (i Interest) ToTag() nostr.Tag {
+	if i.Set != nil {
+		return i.Set.ToTag()
+	}
+	return nostr.Tag{"t", i.Hashtag}
+}
+
+// Emoji is an item in an emoji list, either a single custom emoji or a pointer to an emoji set (kind 30030).
+type Emoji struct {
+	Shortcode string
+	URL       string
+	Set       *AddressRef
+}
+
+func (e Emoji) Value() string {
+	if e.Set != nil {
+		return "a:" + e.Set.Value()
+	}
+	return "emoji:" + e.Shortcode
+}
+
+func (e Emoji) ToTag() nostr.Tag {
+	if e.Set != nil {
+		return e.Set.ToTag()
+	}
+	return nostr.Tag{"emoji", e.Shortcode, e.URL}
+}
+
+func (sys *System) FetchPinnedNotes(ctx context.Context, pubkey string) PinnedNotesList {
+	l, _ := fetchGenericList(sys, ctx, pubkey, 10001, parseEventRef, sys.PinnedNotesCache, false)
+	return l
+}
+
+func (sys *System) FetchBookmarks(ctx context.Context, pubkey string) BookmarkList {
+	l, _ := fetchGenericList(sys, ctx, pubkey, 10003, parseBookmark, sys.BookmarkListCache, false)
+	return l
+}
+
+func (sys *System) FetchCommunities(ctx context.Context, pubkey string) CommunityList {
+	l, _ := fetchGenericList(sys, ctx, pubkey, 10004, parseCommunity, sys.CommunityListCache, false)
+	return l
+}
+
+func (sys *System) FetchPublicChats(ctx context.Context, pubkey string) PublicChatList {
+	l, _ := fetchGenericList(sys, ctx, pubkey, 10005, parseEventRef, sys.PublicChatListCache, false)
+	return l
+}
+
+func (sys *System) FetchBlockedRelays(ctx context.Context, pubkey string) BlockedRelayList {
+	l, _ := fetchGenericList(sys, ctx, pubkey, 10006, parseRelayURL, sys.BlockedRelayListCache, false)
+	return l
+}
+
+func (sys *System) FetchSearchRelays(ctx context.Context, pubkey string) SearchRelayList {
+	l, _ := fetchGenericList(sys, ctx, pubkey, 10007, parseRelayURL, sys.SearchRelayListCache, false)
+	return l
+}
+
+func (sys *System) FetchInterests(ctx context.Context, pubkey string) InterestList {
+	l, _ := fetchGenericList(sys, ctx, pubkey, 10015, parseInterest, sys.InterestListCache, false)
+	return l
+}
+
+func (sys *System) FetchEmojiList(ctx context.Context, pubkey string) EmojiList {
+	l, _ := fetchGenericList(sys, ctx, pubkey, 10030, parseEmoji, sys.EmojiListCache, false)
+	return l
+}
+
+func parseEventRef(tag nostr.Tag) (e EventRef, ok bool) {
+	if len(tag) < 2 || tag[0] != "e" {
+		return e, false
+	}
+	if !nostr.IsValid32ByteHex(tag[1]) {
+		return e, false
+	}
+
+	e.ID = tag[1]
+	if len(tag) > 2 && nostr.IsValidRelayURL(tag[2]) {
+		e.Relay = nostr.NormalizeURL(tag[2])
+	}
+	return e, true
+}
+
+func parseAddressRef(tag nostr.Tag) (a AddressRef, ok bool) {
+	if len(tag) < 2 || tag[0] != "a" {
+		return a, false
+	}
+
+	spl := strings.SplitN(tag[1], ":", 3)
+	if len(spl) != 3 {
+		return a, false
+	}
+	kind, err := strconv.Atoi(spl[0])
+	if err != nil {
+		return a, false
+	}
+	if !nostr.IsValidPublicKey(spl[1]) {
+		return a, false
+	}
+
+	a.Kind = kind
+	a.PubKey = spl[1]
+	a.Identifier = spl[2]
+	if len(tag) > 2 && nostr.IsValidRelayURL(tag[2]) {
+		a.Relay = nostr.NormalizeURL(tag[2])
+	}
+	return a, true
+}
+
+func parseRelayURL(tag nostr.Tag) (r RelayURL, ok bool) {
+	if len(tag) < 2 || tag[0] != "relay" {
+		return r, false
+	}
+	if !nostr.IsValidRelayURL(tag[1]) {
+		return r, false
+	}
+	return RelayURL(nostr.NormalizeURL(tag[1])), true
+}
+
+func parseCommunity(tag nostr.Tag) (a AddressRef, ok bool) {
+	a, ok = parseAddressRef(tag)
+	if !ok || a.Kind != 34550 {
+		return a, false
+	}
+	return a, true
+}
+
+func parseBookmark(tag nostr.Tag) (b Bookmark, ok bool) {
+	if len(tag) < 2 {
+		return b, false
+	}
+
+	switch tag[0] {
+	case "e":
+		if e, ok := parseEventRef(tag); ok {
+			b.Event = &e
+			return b, true
+		}
+	case "a":
+		if a, ok := parseAddressRef(tag); ok {
+			b.Address = &a
+			return b, true
+		}
+	case "t":
+		if t := parseHashtag(tag[1]); t != "" {
+			b.Hashtag = t
+			return b, true
+		}
+	case "r":
+		if u := strings.TrimSpace(tag[1]); u != "" {
+			b.URL = u
+			return b, true
+		}
+	}
+
+	return b, false
+}
+
+func parseInterest(tag nostr.Tag) (i Interest, ok bool) {
+	if len(tag) < 2 {
+		return i, false
+	}
+
+	switch tag[0] {
+	case "t":
+		if t := parseHashtag(tag[1]); t != "" {
+			i.Hashtag = t
+			return i, true
+		}
+	case "a":
+		if a, ok := parseAddressRef(tag); ok && a.Kind == 30015 {
+			i.Set = &a
+			return i, true
+		}
+	}
+
+	return i, false
+}
+
+func parseEmoji(tag nostr.Tag) (e Emoji, ok bool) {
+	if len(tag) < 2 {
+		return e, false
+	}
+
+	switch tag[0] {
+	case "emoji":
+		if len(tag) < 3 || tag[1] == "" || tag[2] == "" {
+			return e, false
+		}
+		e.Shortcode = tag[1]
+		e.URL = tag[2]
+		return e, true
+	case "a":
+		if a, ok := parseAddressRef(tag); ok && a.Kind == 30030 {
+			e.Set = &a
+			return e, true
+		}
+	}
+
+	return e, false
+}
+
+func parseHashtag(t string) string {
+	return strings.ToLower(strings.TrimPrefix(strings.TrimSpace(t), "#"))
+}
+
+// supportsPrivateItems tells if lists of this kind can have private items encrypted in their content.
+// that's the case for NIP-51 lists, but not for follow lists and relay lists.
+func supportsPrivateItems(kind int) bool {
+	return kind >= 10000 && kind != 10002
+}
+
+// mergeActingUserPrivateItems decrypts the private items of a list owned by the acting user, if we have their
+// cipher, and adds them to the list.
+//
+// this must be called every time a list is read, after it was saved in the cache, since the caches only ever
+// hold the public items (they may be shared or persisted on disk).
+func mergeActingUserPrivateItems[I TagItemWithValue](
+	ctx context.Context,
+	sys *System,
+	l *GenericList[I],
+	parseTag func(nostr.Tag) (I, bool),
+) {
+	if l.Event == nil || l.Private != nil || !supportsPrivateItems(l.Event.Kind) {
+		return
+	}
+
+	tags, ok := sys.actingUserPrivateTags(ctx, l.Event)
+	if !ok {
+		return
+	}
+
+	// don't touch the slice that may be shared with the cache
+	l.Items = slices.Clone(l.Items)
+	mergePrivateItems(l, tags, parseTag)
+}
+
+// mergePrivateItems adds the items from the given private tags to the list, marking them as private.
+// items that are also in the public part of the list are not marked.
+func mergePrivateItems[I TagItemWithValue](l *GenericList[I], tags nostr.Tags, parseTag func(nostr.Tag) (I, bool)) {
+	if l.Private == nil {
+		l.Private = make(map[string]bool)
+	}
+	for _, tag := range tags {
+		item, ok := parseTag(tag)
+		if !ok {
+			continue
+		}
+		if slices.ContainsFunc(l.Items, func(i I) bool { return i.Value() == item.Value() }) {
+			continue
+		}
+		l.Items = append(l.Items, item)
+		l.Private[item.Value()] = true
+	}
+}
+
+// decryptPrivateTags returns the tags encrypted to self in the content of a NIP-51 list.
+// lists encrypted with NIP-04 by older clients can only be decrypted if the cipher is also a
+// keyring.NIP04Decrypter. when saved again they are always encrypted with NIP-44.
+func decryptPrivateTags(ctx context.Context, cipher keyring.Cipher, evt *nostr.Event) (nostr.Tags, error) {
+	if evt.Content == "" {
+		return nostr.Tags{}, nil
+	}
+
+	var plaintext string
+	var err error
+	if strings.Contains(evt.Content, "?iv=") {
+		nip04, ok := cipher.(keyring.NIP04Decrypter)
+		if !ok {
+			return nil, fmt.Errorf("private items are encrypted with NIP-04, which this cipher can't decrypt")
+		}
+		plaintext, err = nip04.NIP04Decrypt(ctx, evt.Content, evt.PubKey)
+	} else {
+		plaintext, err = cipher.Decrypt(ctx, evt.Content, evt.PubKey)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt private items: %w", err)
+	}
+
+	var tags nostr.Tags
+	if err := json.Unmarshal([]byte(plaintext), &tags); err != nil {
+		return nil, fmt.Errorf("failed to decode private items: %w", err)
+	}
+	return tags, nil
+}
+
+// encryptPrivateTags is the opposite of decryptPrivateTags.
+func encryptPrivateTags(ctx context.Context, cipher keyring.Cipher, pubkey string, tags nostr.Tags) (string, error) {
+	if len(tags) == 0 {
+		return "", nil
+	}
+
+	plaintext, _ := json.Marshal(tags)
+	content, err := cipher.Encrypt(ctx, string(plaintext), pubkey)
+	if err != nil {
+		return "", fmt.Errorf("failed to encrypt private items: %w", err)
+	}
+	return content, nil
+}
@@ -0,0 +1,142 @@
+package sdk
+
+import (
+	"testing"
+
+	"github.com/nbd-wtf/go-nostr"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	nip51TestPubKey = "3bf0c63fcb93463407af97a5e5ee64fa883d107ef9e558472c4eb9aaaefa459d"
+	nip51TestID     = "31d7c2875b5fc8e6f9c8f9dc1f84de1b6b91d1947ea4c59225e55c325d330fa8"
+)
+
+// parseTestCase is a tag and what it should be parsed into. when canonical is set, that's the tag the item
+// is written back as, otherwise it must be written back exactly as the input.
+type parseTestCase[I TagItemWithValue] struct {
+	tag       nostr.Tag
+	ok        bool
+	item      I
+	canonical nostr.Tag
+}
+
+func testParser[I TagItemWithValue](t *testing.T, parse func(nostr.Tag) (I, bool), cases []parseTestCase[I]) {
+	t.Helper()
+	for _, c := range cases {
+		item, ok := parse(c.tag)
+		require.Equal(t, c.ok, ok, "%v", c.tag)
+		if !ok {
+			continue
+		}
+		require.Equal(t, c.item, item, "%v", c.tag)
+
+		out := item.ToTag()
+		if c.canonical != nil {
+			require.Equal(t, c.canonical, out, "%v", c.tag)
+		} else {
+			require.Equal(t, c.tag, out, "%v", c.tag)
+		}
+
+		// and parsing what we write gives the same item back
+		again, ok := parse(out)
+		require.True(t, ok, "%v", out)
+		require.Equal(t, item, again, "%v", out)
+	}
+}
+
+func TestParseNIP51Items(t *testing.T) {
+	addr := "30023:" + nip51TestPubKey + ":my-article"
+	community := "34550:" + nip51TestPubKey + ":cats"
+	interestSet := "30015:" + nip51TestPubKey + ":stuff"
+	emojiSet := "30030:" + nip51TestPubKey + ":faces"
+
+	t.Run("event", func(t *testing.T) {
+		testParser(t, parseEventRef, []parseTestCase[EventRef]{
+			{tag: nostr.Tag{"e", nip51TestID}, ok: true, item: EventRef{ID: nip51TestID}},
+			{tag: nostr.Tag{"e", nip51TestID, "wss://relay.com"}, ok: true, item: EventRef{ID: nip51TestID, Relay: "wss://relay.com"}},
+			{
+				tag: nostr.Tag{"e", nip51TestID, "wss://Relay.com/", "extra"}, ok: true,
+				item:      EventRef{ID: nip51TestID, Relay: "wss://relay.com"},
+				canonical: nostr.Tag{"e", nip51TestID, "wss://relay.com"},
+			},
+			{
+				tag: nostr.Tag{"e", nip51TestID, ""}, ok: true,
+				item:      EventRef{ID: nip51TestID},
+				canonical: nostr.Tag{"e", nip51TestID},
+			},
+			{
+				tag: nostr.Tag{"e", nip51TestID, "not a relay"}, ok: true,
+				item:      EventRef{ID: nip51TestID},
+				canonical: nostr.Tag{"e", nip51TestID},
+			},
+			{tag: nostr.Tag{"e", "xyz"}},
+			{tag: nostr.Tag{"p", nip51TestID}},
+			{tag: nostr.Tag{"e"}},
+		})
+	})
+
+	t.Run("address", func(t *testing.T) {
+		testParser(t, parseAddressRef, []parseTestCase[AddressRef]{
+			{tag: nostr.Tag{"a", addr}, ok: true, item: AddressRef{Kind: 30023, PubKey: nip51TestPubKey, Identifier: "my-article"}},
+			{
+				tag: nostr.Tag{"a", addr, "wss://relay.com"}, ok: true,
+				item: AddressRef{Kind: 30023, PubKey: nip51TestPubKey, Identifier: "my-article", Relay: "wss://relay.com"},
+			},
+			{tag: nostr.Tag{"a", "30023:" + nip51TestPubKey + ":"}, ok: true, item: AddressRef{Kind: 30023, PubKey: nip51TestPubKey}},
+			{tag: nostr.Tag{"a", "30023:" + nip51TestPubKey}},
+			{tag: nostr.Tag{"a", "x:" + nip51TestPubKey + ":d"}},
+			{tag: nostr.Tag{"a", "30023:nobody:d"}},
+		})
+	})
+
+	t.Run("community", func(t *testing.T) {
+		testParser(t, parseCommunity, []parseTestCase[AddressRef]{
+			{tag: nostr.Tag{"a", community}, ok: true, item: AddressRef{Kind: 34550, PubKey: nip51TestPubKey, Identifier: "cats"}},
+			{tag: nostr.Tag{"a", addr}}, // wrong kind
+		})
+	})
+
+	t.Run("relay", func(t *testing.T) {
+		testParser(t, parseRelayURL, []parseTestCase[RelayURL]{
+			{tag: nostr.Tag{"relay", "wss://relay.com"}, ok: true, item: "wss://relay.com"},
+			{tag: nostr.Tag{"relay", "wss://Relay.com/"}, ok: true, item: "wss://relay.com", canonical: nostr.Tag{"relay", "wss://relay.com"}},
+			{tag: nostr.Tag{"relay", "relay.com"}},
+			{tag: nostr.Tag{"r", "wss://relay.com"}},
+		})
+	})
+
+	t.Run("bookmark", func(t *testing.T) {
+		testParser(t, parseBookmark, []parseTestCase[Bookmark]{
+			{tag: nostr.Tag{"e", nip51TestID}, ok: true, item: Bookmark{Event: &EventRef{ID: nip51TestID}}},
+			{tag: nostr.Tag{"a", addr}, ok: true, item: Bookmark{Address: &AddressRef{Kind: 30023, PubKey: nip51TestPubKey, Identifier: "my-article"}}},
+			{tag: nostr.Tag{"t", "nostr"}, ok: true, item: Bookmark{Hashtag: "nostr"}},
+			{tag: nostr.Tag{"t", " #Nostr "}, ok: true, item: Bookmark{Hashtag: "nostr"}, canonical: nostr.Tag{"t", "nostr"}},
+			{tag: nostr.Tag{"r", "https://example.com"}, ok: true, item: Bookmark{URL: "https://example.com"}},
+			{tag: nostr.Tag{"t", "#"}},
+			{tag: nostr.Tag{"r", " "}},
+			{tag: nostr.Tag{"p", nip51TestPubKey}},
+		})
+	})
+
+	t.Run("interest", func(t *testing.T) {
+		testParser(t, parseInterest, []parseTestCase[Interest]{
+			{tag: nostr.Tag{"t", "bitcoin"}, ok: true, item: Interest{Hashtag: "bitcoin"}},
+			{tag: nostr.Tag{"t", "#Bitcoin"}, ok: true, item: Interest{Hashtag: "bitcoin"}, canonical: nostr.Tag{"t", "bitcoin"}},
+			{tag: nostr.Tag{"a", interestSet}, ok: true, item: Interest{Set: &AddressRef{Kind: 30015, PubKey: nip51TestPubKey, Identifier: "stuff"}}},
+			{tag: nostr.Tag{"a", emojiSet}}, // wrong kind
+			{tag: nostr.Tag{"e", nip51TestID}},
+		})
+	})
+
+	t.Run("emoji", func(t *testing.T) {
+		testParser(t, parseEmoji, []parseTestCase[Emoji]{
+			{tag: nostr.Tag{"emoji", "soapbox", "https://example.com/soapbox.png"}, ok: true, item: Emoji{Shortcode: "soapbox", URL: "https://example.com/soapbox.png"}},
+			{tag: nostr.Tag{"a", emojiSet}, ok: true, item: Emoji{Set: &AddressRef{Kind: 30030, PubKey: nip51TestPubKey, Identifier: "faces"}}},
+			{tag: nostr.Tag{"emoji", "soapbox"}},     // missing url
+			{tag: nostr.Tag{"emoji", "soapbox", ""}}, // empty url
+			{tag: nostr.Tag{"emoji", "", "https://example.com/soapbox.png"}},
+			{tag: nostr.Tag{"a", interestSet}}, // wrong kind
+		})
+	})
+}
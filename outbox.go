@@ -3,32 +3,52 @@ package sdk
 import (
 	"context"
 	"fmt"
+	"slices"
 	"sync"
 	"time"
 
 	"github.com/nbd-wtf/go-nostr"
 )
 
+// FetchOutboxRelays returns up to n relays the given user is likely to be publishing to: the ones marked as
+// "write" in their relay list come first, then the ones with the best scores in the hints database.
 func (sys *System) FetchOutboxRelays(ctx context.Context, pubkey string, n int) []string {
 	if relays, ok := sys.outboxShortTermCache.Get(pubkey); ok {
+		relays = sys.filterBlockedRelays(relays)
 		if len(relays) > n {
 			relays = relays[0:n]
 		}
 		return relays
 	}
 
-	if rl, ok := sys.RelayListCache.Get(pubkey); !ok || (rl.Event != nil && rl.Event.CreatedAt < nostr.Now()-60*60*24*7) {
-		// try to fetch relays list again if we don't have one or if ours is a week old
-		fetchGenericList(sys, ctx, pubkey, 10002, parseRelayFromKind10002, sys.RelayListCache, false)
-	}
+	rl := sys.fetchRelayListForOutbox(ctx, pubkey)
 
-	relays := sys.Hints.TopN(pubkey, 6)
+	relays := make([]string, 0, 6)
+	for _, r := range rl.Items {
+		if r.Outbox && !IsVirtualRelay(r.URL) {
+			relays = append(relays, r.URL)
+			if len(relays) == 6 {
+				break
+			}
+		}
+	}
+	if len(relays) < 6 {
+		for _, url := range sys.Hints.TopN(pubkey, 6) {
+			if !slices.Contains(relays, url) {
+				relays = append(relays, url)
+				if len(relays) == 6 {
+					break
+				}
+			}
+		}
+	}
 
 	if len(relays) == 0 {
-		return []string{"wss://relay.damus.io", "wss://nos.lol"}
+		return sys.filterBlockedRelays([]string{"wss://relay.damus.io", "wss://nos.lol"})
 	}
 
 	sys.outboxShortTermCache.SetWithTTL(pubkey, relays, time.Minute*2)
+	relays = sys.filterBlockedRelays(relays)
 
 	if len(relays) > n {
 		relays = relays[0:n]
@@ -37,6 +57,37 @@ func (sys *System) FetchOutboxRelays(ctx context.Context, pubkey string, n int)
 	return relays
 }
 
+// FetchInboxRelays returns up to n relays the given user is likely to be reading from, i.e. the ones marked
+// as "read" in their relay list, or, if they don't have these, the ones with the best scores in the hints database.
+func (sys *System) FetchInboxRelays(ctx context.Context, pubkey string, n int) []string {
+	rl := sys.fetchRelayListForOutbox(ctx, pubkey)
+
+	relays := make([]string, 0, n)
+	for _, r := range rl.Items {
+		if r.Inbox && !IsVirtualRelay(r.URL) && !sys.IsRelayBlocked(r.URL) {
+			relays = append(relays, r.URL)
+			if len(relays) == n {
+				break
+			}
+		}
+	}
+
+	if len(relays) == 0 {
+		return sys.filterBlockedRelays(sys.Hints.TopN(pubkey, n))
+	}
+
+	return relays
+}
+
+func (sys *System) fetchRelayListForOutbox(ctx context.Context, pubkey string) RelayList {
+	rl, ok := sys.RelayListCache.Get(pubkey)
+	if !ok || (rl.Event != nil && rl.Event.CreatedAt < nostr.Now()-60*60*24*7) {
+		// try to fetch relays list again if we don't have one or if ours is a week old
+		rl, _ = fetchGenericList(sys, ctx, pubkey, 10002, parseRelayFromKind10002, sys.RelayListCache, false)
+	}
+	return rl
+}
+
 func (sys *System) ExpandQueriesByAuthorAndRelays(
 	ctx context.Context,
 	filter nostr.Filter,
@@ -79,9 +130,10 @@ func (sys *System) ExpandQueriesByAuthorAndRelays(
 			flt, ok := filterForRelay[relay]
 			if !ok {
 				flt = filter.Clone()
-				filterForRelay[relay] = flt
+				flt.Authors = make([]string, 0, n)
 			}
 			flt.Authors = append(flt.Authors, pubkey)
+			filterForRelay[relay] = flt
 		}
 	}
 
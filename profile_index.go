@@ -0,0 +1,217 @@
+package sdk
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/fiatjaf/eventstore"
+	"github.com/nbd-wtf/go-nostr"
+)
+
+// SearchLocalProfiles searches the profiles we have in the local store by name, display name, NIP-05 address
+// and about text, without touching the network. Queries are matched by prefix and, for longer words, with some
+// tolerance for typos, so this is suitable for autocompleting mentions.
+func (sys *System) SearchLocalProfiles(query string, limit int) []ProfileMetadata {
+	sys.profileIndex.loadOnce.Do(func() {
+		sys.profileIndex.load(context.Background(), sys.Store)
+	})
+	return sys.profileIndex.search(query, limit)
+}
+
+// indexProfile adds or updates a profile in the local search index.
+func (sys *System) indexProfile(pm ProfileMetadata) {
+	if pm.Event == nil {
+		return
+	}
+	sys.profileIndex.add(pm)
+}
+
+type profileIndex struct {
+	loadOnce sync.Once
+
+	mu       sync.RWMutex
+	profiles map[string]indexedProfile // { [pubkey]: profile }
+}
+
+type indexedProfile struct {
+	meta  ProfileMetadata
+	names []string // full name and display name, lowercased
+	words []string // individual words from names and nip05
+	about []string // individual words from the about text
+}
+
+func newProfileIndex() *profileIndex {
+	return &profileIndex{
+		profiles: make(map[string]indexedProfile, 1000),
+	}
+}
+
+// load reads all kind 0 events from the store into the index.
+func (pi *profileIndex) load(ctx context.Context, store eventstore.Store) {
+	ch, err := store.QueryEvents(ctx, nostr.Filter{Kinds: []int{0}, Limit: 1_000_000})
+	if err != nil {
+		return
+	}
+	for evt := range ch {
+		if pm, err := ParseMetadata(evt); err == nil {
+			pi.add(pm)
+		}
+	}
+}
+
+func (pi *profileIndex) add(pm ProfileMetadata) {
+	pi.mu.Lock()
+	defer pi.mu.Unlock()
+
+	if curr, ok := pi.profiles[pm.PubKey]; ok && curr.meta.Event.CreatedAt >= pm.Event.CreatedAt {
+		return
+	}
+
+	ip := indexedProfile{meta: pm}
+	for _, name := range []string{pm.Name, pm.DisplayName} {
+		if name = strings.ToLower(strings.TrimSpace(name)); name != "" {
+			ip.names = append(ip.names, name)
+			ip.words = append(ip.words, splitWords(name)...)
+		}
+	}
+	if nip05 := strings.ToLower(pm.NIP05); nip05 != "" {
+		ip.words = append(ip.words, strings.TrimPrefix(nip05, "_@"))
+		ip.words = append(ip.words, splitWords(nip05)...)
+	}
+	ip.about = splitWords(strings.ToLower(pm.About))
+
+	pi.profiles[pm.PubKey] = ip
+}
+
+func (pi *profileIndex) search(query string, limit int) []ProfileMetadata {
+	terms := splitWords(strings.ToLower(query))
+	if len(terms) == 0 {
+		return nil
+	}
+	full := strings.Join(terms, " ")
+
+	type scored struct {
+		meta  ProfileMetadata
+		score int
+	}
+	results := make([]scored, 0, limit)
+
+	pi.mu.RLock()
+	for _, ip := range pi.profiles {
+		total := 0
+		for _, name := range ip.names {
+			if name == full {
+				total += 100
+				break
+			} else if strings.HasPrefix(name, full) {
+				total += 50
+				break
+			}
+		}
+
+		for _, term := range terms {
+			score := matchTerm(term, ip.words, 20, 10)
+			if score == 0 {
+				score = matchTerm(term, ip.about, 3, 1)
+			}
+			if score == 0 {
+				// all terms must match something
+				total = 0
+				break
+			}
+			total += score
+		}
+
+		if total > 0 {
+			results = append(results, scored{ip.meta, total})
+		}
+	}
+	pi.mu.RUnlock()
+
+	slices.SortFunc(results, func(a, b scored) int {
+		if c := cmp.Compare(b.score, a.score); c != 0 {
+			return c
+		}
+		return cmp.Compare(b.meta.Event.CreatedAt, a.meta.Event.CreatedAt)
+	})
+
+	if limit > 0 && len(results) > limit {
+		results = results[0:limit]
+	}
+
+	profiles := make([]ProfileMetadata, len(results))
+	for i, r := range results {
+		profiles[i] = r.meta
+	}
+	return profiles
+}
+
+// matchTerm returns prefixScore if the term is a prefix of any of the words, fuzzyScore if it is close
+// enough to one of them, or zero.
+func matchTerm(term string, words []string, prefixScore int, fuzzyScore int) int {
+	best := 0
+	for _, word := range words {
+		if strings.HasPrefix(word, term) {
+			return prefixScore
+		}
+
+		// only allow typos in longer words, compared against the same number of characters
+		if best == 0 && len(term) >= 4 {
+			maxDistance := 1
+			if len(term) >= 8 {
+				maxDistance = 2
+			}
+			candidate := []rune(word)
+			if n := len([]rune(term)); len(candidate) > n {
+				candidate = candidate[0:n]
+			}
+			if levenshtein(term, string(candidate), maxDistance) <= maxDistance {
+				best = fuzzyScore
+			}
+		}
+	}
+	return best
+}
+
+func splitWords(s string) []string {
+	return strings.FieldsFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
+
+// levenshtein computes the edit distance between a and b, giving up as soon as it is bigger than limit.
+func levenshtein(a, b string, limit int) int {
+	ra, rb := []rune(a), []rune(b)
+	if d := len(ra) - len(rb); d > limit || -d > limit {
+		return limit + 1
+	}
+
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		rowMin := curr[0]
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+			rowMin = min(rowMin, curr[j])
+		}
+		if rowMin > limit {
+			return limit + 1
+		}
+		prev, curr = curr, prev
+	}
+
+	return prev[len(rb)]
+}
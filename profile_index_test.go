@@ -0,0 +1,58 @@
+package sdk
+
+import (
+	"testing"
+
+	"github.com/nbd-wtf/go-nostr"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProfileIndex(t *testing.T) {
+	pi := newProfileIndex()
+
+	profile := func(pubkey string, createdAt nostr.Timestamp, name, displayName, nip05, about string) ProfileMetadata {
+		return ProfileMetadata{
+			PubKey:      pubkey,
+			Event:       &nostr.Event{PubKey: pubkey, Kind: 0, CreatedAt: createdAt},
+			Name:        name,
+			DisplayName: displayName,
+			NIP05:       nip05,
+			About:       about,
+		}
+	}
+
+	pi.add(profile("a", 10, "fiatjaf", "", "_@fiatjaf.com", "i make nostr things"))
+	pi.add(profile("b", 10, "jack", "Jack Dorsey", "", "no state is the best state"))
+	pi.add(profile("c", 10, "fiat", "Fiat Money", "fiat@money.com", "printer goes brrr"))
+	pi.add(profile("d", 10, "alice", "", "", "likes fiatjaf"))
+
+	names := func(profiles []ProfileMetadata) []string {
+		res := make([]string, len(profiles))
+		for i, p := range profiles {
+			res[i] = p.Name
+		}
+		return res
+	}
+
+	// exact name match comes first, then prefix matches, then matches in the about text
+	require.Equal(t, []string{"fiat", "fiatjaf", "alice"}, names(pi.search("fiat", 10)))
+	require.Equal(t, []string{"fiatjaf", "alice"}, names(pi.search("fiatjaf", 10)))
+	require.Equal(t, []string{"fiat"}, names(pi.search("fiat", 1)))
+
+	// multiple words must all match
+	require.Equal(t, []string{"jack"}, names(pi.search("jack dor", 10)))
+	require.Empty(t, pi.search("jack money", 10))
+
+	// nip05
+	require.Equal(t, []string{"fiat"}, names(pi.search("money.com", 10)))
+
+	// typos
+	require.Equal(t, []string{"jack"}, names(pi.search("dorsy", 10)))
+	require.Empty(t, pi.search("jck", 10))
+
+	// older versions don't replace newer ones
+	pi.add(profile("b", 5, "jack", "", "", ""))
+	require.Equal(t, []string{"jack"}, names(pi.search("dorsey", 10)))
+	pi.add(profile("b", 20, "jack", "", "", ""))
+	require.Empty(t, pi.search("dorsey", 10))
+}
@@ -0,0 +1,126 @@
+package sdk
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/nbd-wtf/go-nostr"
+	"github.com/nbd-wtf/nostr-sdk/keyring"
+)
+
+// PublishResult is the outcome of sending an event to a single relay, Error is nil when the relay
+// has accepted it.
+type PublishResult struct {
+	RelayURL string
+	Error    error
+}
+
+// Publish signs the given event and sends it to the author's outbox relays and to the inbox relays of every
+// user tagged in it, following the outbox model. It returns the signed event and the result from each relay.
+func (sys *System) Publish(
+	ctx context.Context,
+	signer keyring.Signer,
+	evt nostr.Event,
+) (nostr.Event, []PublishResult, error) {
+	return sys.publish(ctx, signer, evt, nil)
+}
+
+// publish is like Publish, but also sends the event to the given extra relays.
+func (sys *System) publish(
+	ctx context.Context,
+	signer keyring.Signer,
+	evt nostr.Event,
+	extraRelays []string,
+) (nostr.Event, []PublishResult, error) {
+	if evt.CreatedAt == 0 {
+		evt.CreatedAt = nostr.Now()
+	}
+	if err := signer.SignEvent(ctx, &evt); err != nil {
+		return evt, nil, fmt.Errorf("failed to sign event: %w", err)
+	}
+
+	relays := sys.determineRelaysToPublish(ctx, &evt)
+	for _, url := range sys.filterBlockedRelays(extraRelays) {
+		if !slices.Contains(relays, url) {
+			relays = append(relays, url)
+		}
+	}
+	if len(relays) == 0 {
+		return evt, nil, fmt.Errorf("no relays to publish to")
+	}
+
+	// save it locally too
+	sys.StoreRelay.Publish(ctx, evt)
+
+	return evt, sys.publishToRelays(ctx, relays, evt), nil
+}
+
+// determineRelaysToPublish returns the author's outbox relays plus the inbox relays of everybody
+// that is mentioned in a "p" tag.
+func (sys *System) determineRelaysToPublish(ctx context.Context, evt *nostr.Event) []string {
+	relays := make([]string, 0, 10)
+	for _, url := range sys.FetchOutboxRelays(ctx, evt.PubKey, 5) {
+		if !slices.Contains(relays, url) {
+			relays = append(relays, url)
+		}
+	}
+
+	targets := make([]string, 0, len(evt.Tags))
+	for _, tag := range evt.Tags {
+		if len(tag) >= 2 && tag[0] == "p" && tag[1] != evt.PubKey && nostr.IsValidPublicKey(tag[1]) &&
+			!slices.Contains(targets, tag[1]) {
+			targets = append(targets, tag[1])
+		}
+	}
+
+	mu := sync.Mutex{}
+	wg := sync.WaitGroup{}
+	wg.Add(len(targets))
+	for _, pubkey := range targets {
+		go func(pubkey string) {
+			defer wg.Done()
+			inbox := sys.FetchInboxRelays(ctx, pubkey, 3)
+
+			mu.Lock()
+			defer mu.Unlock()
+			for _, url := range inbox {
+				if !slices.Contains(relays, url) {
+					relays = append(relays, url)
+				}
+			}
+		}(pubkey)
+	}
+	wg.Wait()
+
+	return sys.filterBlockedRelays(relays)
+}
+
+// publishToRelays sends the event to all the given relays concurrently and waits for their responses.
+func (sys *System) publishToRelays(ctx context.Context, relays []string, evt nostr.Event) []PublishResult {
+	results := make([]PublishResult, len(relays))
+
+	wg := sync.WaitGroup{}
+	wg.Add(len(relays))
+	for i, url := range relays {
+		go func(i int, url string) {
+			defer wg.Done()
+			results[i].RelayURL = url
+
+			relay, err := sys.Pool.EnsureRelay(url)
+			if err != nil {
+				results[i].Error = fmt.Errorf("failed to connect: %w", err)
+				return
+			}
+
+			ctx, cancel := context.WithTimeout(ctx, time.Second*7)
+			defer cancel()
+			results[i].Error = relay.Publish(ctx, evt)
+		}(i, url)
+	}
+	wg.Wait()
+
+	return results
+}
@@ -1,7 +1,12 @@
 package sdk
 
 import (
+	"context"
+	"slices"
+
 	"github.com/nbd-wtf/go-nostr"
+	"github.com/nbd-wtf/nostr-sdk/hints"
+	"github.com/nbd-wtf/nostr-sdk/keyring"
 )
 
 type RelayList = GenericList[Relay]
@@ -14,6 +19,17 @@ type Relay struct {
 
 func (r Relay) Value() string { return r.URL }
 
+func (r Relay) ToTag() nostr.Tag {
+	switch {
+	case r.Inbox && !r.Outbox:
+		return nostr.Tag{"r", r.URL, "read"}
+	case r.Outbox && !r.Inbox:
+		return nostr.Tag{"r", r.URL, "write"}
+	default:
+		return nostr.Tag{"r", r.URL}
+	}
+}
+
 func parseRelayFromKind10002(tag nostr.Tag) (rl Relay, ok bool) {
 	if u := tag.Value(); u != "" && tag[0] == "r" {
 		if !nostr.IsValidRelayURL(u) {
@@ -39,3 +55,86 @@ func parseRelayFromKind10002(tag nostr.Tag) (rl Relay, ok bool) {
 
 	return rl, false
 }
+
+// UpdateRelayList fetches the signer's latest relay list (kind 10002), applies the given change to it and
+// publishes the result to the relays in the old list, to the relays in the new list and to the RelayListRelays.
+// Relays with invalid URLs and relays that are neither inbox nor outbox are removed (the latter would
+// otherwise be written as both). See ModifyList for the safety checks performed.
+//
+// the new relays are immediately used for the signer in outbox-model queries.
+func (sys *System) UpdateRelayList(
+	ctx context.Context,
+	signer keyring.Signer,
+	change func(*RelayList),
+) (RelayList, []PublishResult, error) {
+	rl, results, err := ModifyList(ctx, sys, signer, 10002, func(rl *RelayList) {
+		change(rl)
+		cleanRelayList(rl)
+	})
+	if err != nil || rl.Event == nil {
+		return rl, results, err
+	}
+
+	for _, r := range rl.Items {
+		// only write relays, like trackEventHints does
+		if r.Outbox {
+			sys.Hints.Save(rl.PubKey, r.URL, hints.LastInRelayList, rl.Event.CreatedAt)
+		}
+	}
+	sys.outboxShortTermCache.Delete(rl.PubKey)
+
+	return rl, results, nil
+}
+
+// cleanRelayList normalizes the URLs in a relay list and removes the relays that shouldn't be in it.
+func cleanRelayList(rl *RelayList) {
+	valid := make([]Relay, 0, len(rl.Items))
+	for _, r := range rl.Items {
+		if (r.Inbox || r.Outbox) && nostr.IsValidRelayURL(r.URL) {
+			r.URL = nostr.NormalizeURL(r.URL)
+			valid = append(valid, r)
+		}
+	}
+	rl.Items = valid
+}
+
+// relayListURLs returns the URLs of all the relays in a relay list event, which may be nil.
+func relayListURLs(evt *nostr.Event) []string {
+	if evt == nil {
+		return nil
+	}
+	urls := make([]string, 0, len(evt.Tags))
+	for _, tag := range evt.Tags {
+		if r, ok := parseRelayFromKind10002(tag); ok && !IsVirtualRelay(r.URL) {
+			urls = append(urls, r.URL)
+		}
+	}
+	return urls
+}
+
+// IsRelayBlocked tells if the given relay URL is in the blocked relays list (kind 10006) of the current acting
+// user. the URL doesn't have to be normalized.
+func (sys *System) IsRelayBlocked(url string) bool {
+	sys.acting.RLock()
+	defer sys.acting.RUnlock()
+	return len(sys.acting.blockedRelays) > 0 && slices.Contains(sys.acting.blockedRelays, nostr.NormalizeURL(url))
+}
+
+// filterBlockedRelays returns a copy of the given list without the relays blocked by the acting user.
+func (sys *System) filterBlockedRelays(relays []string) []string {
+	sys.acting.RLock()
+	defer sys.acting.RUnlock()
+
+	if len(sys.acting.blockedRelays) == 0 {
+		return relays
+	}
+
+	filtered := make([]string, 0, len(relays))
+	for _, url := range relays {
+		// URLs from hints are not always normalized, the blocked ones always are
+		if !slices.Contains(sys.acting.blockedRelays, nostr.NormalizeURL(url)) {
+			filtered = append(filtered, url)
+		}
+	}
+	return filtered
+}
@@ -3,6 +3,7 @@ package sdk
 import (
 	"context"
 	"fmt"
+	"slices"
 	"strconv"
 	"sync"
 	"time"
@@ -18,6 +19,7 @@ func (sys *System) initializeDataloaders() {
 	for _, kind := range []int{0, 3, 10000, 10001, 10002, 10003, 10004, 10005, 10006, 10007, 10015, 10030} {
 		sys.replaceableLoaders[kind] = sys.createReplaceableDataloader(kind)
 	}
+	sys.addressableLoader = sys.createAddressableDataloader()
 }
 
 func (sys *System) createReplaceableDataloader(kind int) *dataloader.Loader[string, *nostr.Event] {
@@ -133,18 +135,24 @@ func (sys *System) determineRelaysToQuery(ctx context.Context, pubkey string, ki
 	} else {
 		relays = sys.FetchOutboxRelays(ctx, pubkey, 3)
 	}
+	relays = sys.filterBlockedRelays(relays)
 
 	// use a different set of extra relays depending on the kind
-	for len(relays) < 3 {
+	// (with a limit on attempts since all of these may be blocked)
+	for attempts := 0; len(relays) < 3 && attempts < 10; attempts++ {
+		var next string
 		switch kind {
 		case 0:
-			relays = append(relays, pickNext(sys.MetadataRelays))
+			next = pickNext(sys.MetadataRelays)
 		case 3:
-			relays = append(relays, pickNext(sys.FollowListRelays))
+			next = pickNext(sys.FollowListRelays)
 		case 10002:
-			relays = append(relays, pickNext(sys.RelayListRelays))
+			next = pickNext(sys.RelayListRelays)
 		default:
-			relays = append(relays, pickNext(sys.FallbackRelays))
+			next = pickNext(sys.FallbackRelays)
+		}
+		if !sys.IsRelayBlocked(next) {
+			relays = append(relays, next)
 		}
 	}
 
@@ -192,3 +200,157 @@ func (sys *System) batchReplaceableRelayQueries(
 
 	return all
 }
+
+// indexerRelays returns the relays that specialize in replaceable events of the given kind, if any.
+func (sys *System) indexerRelays(kind int) []string {
+	switch kind {
+	case 0:
+		return sys.MetadataRelays
+	case 3:
+		return sys.FollowListRelays
+	case 10002:
+		return sys.RelayListRelays
+	default:
+		return nil
+	}
+}
+
+// latestReplaceable is the result of fetchLatestReplaceable.
+type latestReplaceable struct {
+	Event           *nostr.Event // the newest version found, locally or on relays, may be nil
+	Local           *nostr.Event // the version we had in the local store, may be nil
+	Reached         int          // how many relays answered our query with an EOSE
+	ReachedIndexers int          // how many of those were indexer relays for this kind
+	Found           int          // how many relays had some version of the event
+}
+
+// checkSafeToReplace tells if the latest version we got can be used as the base for a new version without
+// losing anything, given the newest version we knew about before (known, which may be nil).
+//
+// when nobody has any version and we don't know one either we would be creating the event from scratch, which
+// we only do when enough relays, including an indexer if there are any, have told us they don't have it.
+func (lr latestReplaceable) checkSafeToReplace(known *nostr.Event, hasIndexers bool) error {
+	if lr.Reached == 0 {
+		return fmt.Errorf("couldn't reach any relay to get the current version")
+	}
+
+	if known != nil {
+		if lr.Found == 0 || lr.Event.CreatedAt < known.CreatedAt {
+			return fmt.Errorf("no relay has the version from %s we have locally, refusing to overwrite it",
+				known.CreatedAt.Time().Format(time.DateOnly))
+		}
+		return nil
+	}
+
+	if lr.Found == 0 && (lr.Reached < 2 || (hasIndexers && lr.ReachedIndexers == 0)) {
+		return fmt.Errorf("only %d relays (%d indexers) said they don't have it, not enough to create a new one",
+			lr.Reached, lr.ReachedIndexers)
+	}
+
+	return nil
+}
+
+// fetchLatestReplaceable bypasses the caches and the dataloaders and asks all the relays where a replaceable event
+// may be, along with the local store, for their latest version of it. this is slower, but it should be used
+// before modifying the event so we don't overwrite it with stale data. the newest version is saved to the store.
+func (sys *System) fetchLatestReplaceable(ctx context.Context, pubkey string, kind int) latestReplaceable {
+	var res latestReplaceable
+	filter := nostr.Filter{Kinds: []int{kind}, Authors: []string{pubkey}}
+
+	if events, _ := sys.StoreRelay.QuerySync(ctx, filter); len(events) > 0 {
+		res.Local = events[0]
+		res.Event = events[0]
+	}
+
+	// each relay must be counted only once, and determineRelaysToQuery may repeat them
+	indexers := make([]string, 0, 3)
+	for _, url := range sys.filterBlockedRelays(sys.indexerRelays(kind)) {
+		indexers = append(indexers, nostr.NormalizeURL(url))
+	}
+	relays := make([]string, 0, 6)
+	for _, url := range append(sys.determineRelaysToQuery(ctx, pubkey, kind), indexers...) {
+		if nm := nostr.NormalizeURL(url); nm != "" && !slices.Contains(relays, nm) {
+			relays = append(relays, nm)
+		}
+	}
+
+	qctx, cancel := context.WithTimeout(ctx, time.Second*5)
+	defer cancel()
+
+	mu := sync.Mutex{}
+	wg := sync.WaitGroup{}
+	wg.Add(len(relays))
+	for _, url := range relays {
+		go func(url string) {
+			defer wg.Done()
+
+			relay, err := sys.Pool.EnsureRelay(url)
+			if err != nil {
+				return
+			}
+			events, ok := queryUntilEOSE(qctx, relay, filter)
+			if !ok {
+				return
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			res.Reached++
+			if slices.Contains(indexers, url) {
+				res.ReachedIndexers++
+			}
+			found := false
+			for _, evt := range events {
+				if evt.PubKey != pubkey || evt.Kind != kind {
+					continue
+				}
+				if ok, _ := evt.CheckSignature(); !ok {
+					continue
+				}
+				found = true
+				if res.Event == nil || res.Event.CreatedAt < evt.CreatedAt {
+					res.Event = evt
+				}
+			}
+			if found {
+				res.Found++
+			}
+		}(url)
+	}
+	wg.Wait()
+
+	if res.Event != nil && res.Event != res.Local {
+		sys.StoreRelay.Publish(ctx, *res.Event)
+		sys.actingUserListSaved(res.Event)
+	}
+
+	return res
+}
+
+// queryUntilEOSE is like relay.QuerySync, but it only succeeds if the relay sends an EOSE, so we can be sure
+// we got everything it has. a CLOSED (like when the relay requires auth), a timeout or a disconnection are
+// all failures.
+func queryUntilEOSE(ctx context.Context, relay *nostr.Relay, filter nostr.Filter) ([]*nostr.Event, bool) {
+	sub, err := relay.Subscribe(ctx, nostr.Filters{filter})
+	if err != nil {
+		return nil, false
+	}
+	defer sub.Unsub()
+
+	events := make([]*nostr.Event, 0, 1)
+	for {
+		select {
+		case evt, ok := <-sub.Events:
+			if !ok {
+				return nil, false
+			}
+			events = append(events, evt)
+		case <-sub.EndOfStoredEvents:
+			return events, true
+		case <-sub.ClosedReason:
+			return nil, false
+		case <-ctx.Done():
+			return nil, false
+		}
+	}
+}
@@ -1,24 +1,242 @@
 package sdk
 
 import (
+	"cmp"
 	"context"
+	"encoding/json"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/nbd-wtf/go-nostr"
 )
 
-func (sys *System) SearchUsers(ctx context.Context, query string) []ProfileMetadata {
+// UserSearchResult is a profile found by SearchUsers along with the relays that returned it and its score.
+type UserSearchResult struct {
+	ProfileMetadata
+	Relays []string
+	Score  float64
+}
+
+// MarshalJSON writes the profile fields along with Relays and Score, otherwise the ProfileMetadata methods
+// would be used and only the profile would be written.
+func (r UserSearchResult) MarshalJSON() ([]byte, error) {
+	data, err := json.Marshal(r.ProfileMetadata)
+	if err != nil {
+		return nil, err
+	}
+
+	var merged map[string]any
+	if err := json.Unmarshal(data, &merged); err != nil {
+		return nil, err
+	}
+	merged["Relays"] = r.Relays
+	merged["Score"] = r.Score
+	return json.Marshal(merged)
+}
+
+// UnmarshalJSON is the counterpart of MarshalJSON.
+func (r *UserSearchResult) UnmarshalJSON(data []byte) error {
+	var extra struct {
+		Relays []string
+		Score  float64
+	}
+	if err := json.Unmarshal(data, &extra); err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, &r.ProfileMetadata); err != nil {
+		return err
+	}
+	delete(r.Extra, "Relays")
+	delete(r.Extra, "Score")
+	if len(r.Extra) == 0 {
+		r.Extra = nil
+	}
+	r.Relays = extra.Relays
+	r.Score = extra.Score
+	return nil
+}
+
+// UserSearchScorer assigns a score to a SearchUsers result, higher scores come first.
+type UserSearchScorer func(ctx context.Context, query string, result UserSearchResult) float64
+
+// SearchUsers issues a NIP-50 search for profiles on the acting user's search relays (kind 10007) or, if
+// they don't have any, on the default UserSearchRelays.
+//
+// results are merged by pubkey keeping only the newest version of each profile, then ranked by the
+// System's UserSearchScorer. the profiles found are also saved in the MetadataCache and in the local store.
+func (sys *System) SearchUsers(ctx context.Context, query string) []UserSearchResult {
 	limit := 10
-	profiles := make([]ProfileMetadata, 0, limit*len(sys.UserSearchRelays))
+	relays := sys.determineSearchRelays(ctx, sys.UserSearchRelays)
+	results := make([]UserSearchResult, 0, limit*len(relays))
 
-	for ie := range sys.Pool.SubManyEose(ctx, sys.UserSearchRelays, nostr.Filters{
+	for ie := range sys.Pool.SubManyEoseNonUnique(ctx, relays, nostr.Filters{
 		{
+			Kinds:  []int{0},
 			Search: query,
 			Limit:  limit,
 		},
 	}) {
-		m, _ := ParseMetadata(ie.Event)
-		profiles = append(profiles, m)
+		m, err := ParseMetadata(ie.Event)
+		if err != nil {
+			continue
+		}
+
+		idx := slices.IndexFunc(results, func(r UserSearchResult) bool { return r.PubKey == m.PubKey })
+		if idx == -1 {
+			results = append(results, UserSearchResult{ProfileMetadata: m, Relays: []string{ie.Relay.URL}})
+			continue
+		}
+
+		if !slices.Contains(results[idx].Relays, ie.Relay.URL) {
+			results[idx].Relays = append(results[idx].Relays, ie.Relay.URL)
+		}
+		if results[idx].Event.CreatedAt < m.Event.CreatedAt {
+			results[idx].ProfileMetadata = m
+		}
+	}
+
+	for i, res := range results {
+		if cached, ok := sys.MetadataCache.Get(res.PubKey); !ok || cached.Event == nil || cached.Event.CreatedAt < res.Event.CreatedAt {
+			sys.MetadataCache.SetWithTTL(res.PubKey, res.ProfileMetadata, time.Hour*6)
+			sys.StoreRelay.Publish(ctx, *res.Event)
+			sys.indexProfile(res.ProfileMetadata)
+		}
+		results[i].Score = sys.UserSearchScorer(ctx, query, res)
+	}
+
+	slices.SortStableFunc(results, func(a, b UserSearchResult) int {
+		if c := cmp.Compare(b.Score, a.Score); c != 0 {
+			return c
+		}
+		return cmp.Compare(b.Event.CreatedAt, a.Event.CreatedAt)
+	})
+
+	return results
+}
+
+// DefaultUserSearchScore is the default UserSearchScorer. it favors profiles whose name or NIP-05 address
+// match the query exactly, profiles followed by the acting user (or trusted by them, if their follow graph
+// was crawled with CrawlFollowGraph) and profiles returned by more relays.
+func (sys *System) DefaultUserSearchScore(ctx context.Context, query string, res UserSearchResult) float64 {
+	var score float64
+	query = strings.ToLower(strings.TrimSpace(query))
+
+	for _, name := range []string{res.Name, res.DisplayName} {
+		name = strings.ToLower(name)
+		if name == "" {
+			continue
+		}
+		if name == query {
+			score += 100
+			break
+		} else if strings.HasPrefix(name, query) {
+			score += 30
+			break
+		}
+	}
+
+	if nip05 := strings.ToLower(res.NIP05); nip05 != "" {
+		if nip05 == query || strings.TrimPrefix(nip05, "_@") == query {
+			score += 80
+		} else if spl := strings.SplitN(nip05, "@", 2); spl[0] == query {
+			score += 40
+		}
+	}
+
+	if me := sys.ActingUser(); me != "" {
+		if res.PubKey == me {
+			score += 60
+		} else if fl := sys.FetchFollowList(ctx, me); slices.ContainsFunc(fl.Items, func(f Follow) bool {
+			return f.Pubkey == res.PubKey
+		}) {
+			score += 50
+		} else if g := sys.FollowGraph(me); g != nil {
+			// people further away in the acting user's web-of-trust
+			score += 40 * g.TrustScore(res.PubKey)
+		}
+	}
+
+	score += 10 * float64(len(res.Relays))
+
+	return score
+}
+
+// determineSearchRelays returns the search relays (kind 10007) of the acting user, or the given defaults.
+func (sys *System) determineSearchRelays(ctx context.Context, defaults []string) []string {
+	if pubkey := sys.ActingUser(); pubkey != "" {
+		srl := sys.FetchSearchRelays(ctx, pubkey)
+		if len(srl.Items) > 0 {
+			relays := make([]string, len(srl.Items))
+			for i, r := range srl.Items {
+				relays[i] = string(r)
+			}
+			return sys.filterBlockedRelays(relays)
+		}
+	}
+
+	return sys.filterBlockedRelays(defaults)
+}
+
+// NoteSearchOptions are the optional parameters for SearchNotes.
+type NoteSearchOptions struct {
+	Kinds   []int // defaults to kind 1
+	Authors []string
+	Since   nostr.Timestamp
+	Until   nostr.Timestamp
+	Limit   int // defaults to 20
+
+	// by default results are returned in the order they arrive, which should follow the relevance order
+	// given by the relays, but they can be sorted from newest to oldest instead
+	SortByDate bool
+}
+
+// SearchNotes issues a NIP-50 search on the acting user's search relays (kind 10007) or, if they don't have any,
+// on the default NoteSearchRelays. Results are de-duplicated across relays and have their signatures verified.
+func (sys *System) SearchNotes(ctx context.Context, query string, opts NoteSearchOptions) []*nostr.Event {
+	filter := nostr.Filter{
+		Search:  query,
+		Kinds:   opts.Kinds,
+		Authors: opts.Authors,
+		Limit:   opts.Limit,
+	}
+	if len(filter.Kinds) == 0 {
+		filter.Kinds = []int{nostr.KindTextNote}
+	}
+	if filter.Limit == 0 {
+		filter.Limit = 20
+	}
+	if opts.Since != 0 {
+		filter.Since = &opts.Since
+	}
+	if opts.Until != 0 {
+		filter.Until = &opts.Until
+	}
+
+	relays := sys.determineSearchRelays(ctx, sys.NoteSearchRelays)
+	results := make([]*nostr.Event, 0, filter.Limit)
+	seen := make(map[string]struct{}, filter.Limit*len(relays))
+
+	for ie := range sys.Pool.SubManyEose(ctx, relays, nostr.Filters{filter}) {
+		if _, ok := seen[ie.Event.ID]; ok {
+			continue
+		}
+		if ok, _ := ie.Event.CheckSignature(); !ok {
+			continue
+		}
+		seen[ie.Event.ID] = struct{}{}
+		results = append(results, ie.Event)
+	}
+
+	if opts.SortByDate {
+		slices.SortStableFunc(results, func(a, b *nostr.Event) int {
+			return cmp.Compare(b.CreatedAt, a.CreatedAt)
+		})
+	}
+
+	if len(results) > filter.Limit {
+		results = results[0:filter.Limit]
 	}
 
-	return profiles
+	return results
 }
@@ -0,0 +1,195 @@
+package sdk
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/nbd-wtf/go-nostr"
+)
+
+// how often a live subscription will recompute which relays to use for each author
+const replanInterval = time.Minute * 2
+
+// SubscribeUserEvents opens long-lived subscriptions for the given filter on each author's outbox relays and
+// returns a channel that emits every event found, de-duplicated across relays. The channel is closed when ctx is done.
+//
+// relay assignments are recomputed from time to time as the hints change, and immediately when one of the
+// authors publishes a new relay list.
+func (sys *System) SubscribeUserEvents(ctx context.Context, filter nostr.Filter) (<-chan *nostr.Event, error) {
+	if len(filter.Authors) == 0 {
+		return nil, fmt.Errorf("no authors in filter")
+	}
+
+	lus := &liveUserSubscription{
+		sys:    sys,
+		filter: filter,
+		events: make(chan *nostr.Event),
+		seen:   make(map[string]nostr.Timestamp, 500),
+		relays: make(map[string]*liveRelaySubscription),
+		replan: make(chan struct{}, 1),
+	}
+
+	if err := lus.plan(ctx, true); err != nil {
+		return nil, err
+	}
+
+	go lus.watchRelayLists(ctx)
+	go lus.run(ctx)
+
+	return lus.events, nil
+}
+
+type liveUserSubscription struct {
+	sys    *System
+	filter nostr.Filter
+	events chan *nostr.Event
+	wg     sync.WaitGroup
+	replan chan struct{}
+
+	mu     sync.Mutex
+	seen   map[string]nostr.Timestamp        // { [id]: created_at }
+	relays map[string]*liveRelaySubscription // { [relayUrl]: subscription }
+}
+
+type liveRelaySubscription struct {
+	authors []string
+	cancel  context.CancelFunc
+}
+
+func (lus *liveUserSubscription) run(ctx context.Context) {
+	ticker := time.NewTicker(replanInterval)
+	defer ticker.Stop()
+	lastTick := nostr.Now()
+
+	for {
+		select {
+		case <-ctx.Done():
+			// the relay subscriptions will all be canceled by the parent context
+			lus.wg.Wait()
+			close(lus.events)
+			return
+		case <-ticker.C:
+			lus.forget(lastTick)
+			lastTick = nostr.Now()
+			lus.plan(ctx, false)
+		case <-lus.replan:
+			lus.plan(ctx, false)
+		}
+	}
+}
+
+// plan assigns authors to relays and (re)starts the subscriptions on relays whose set of authors has changed.
+func (lus *liveUserSubscription) plan(ctx context.Context, initial bool) error {
+	filters, err := lus.sys.ExpandQueriesByAuthorAndRelays(ctx, lus.filter)
+	if err != nil {
+		return fmt.Errorf("failed to expand queries: %w", err)
+	}
+
+	lus.mu.Lock()
+	defer lus.mu.Unlock()
+
+	// stop what we won't use anymore
+	for url, lrs := range lus.relays {
+		if flt, ok := filters[url]; !ok || !slices.Equal(lrs.authors, sortedAuthors(flt)) {
+			lrs.cancel()
+			delete(lus.relays, url)
+		}
+	}
+
+	// start what is new or has changed
+	for url, flt := range filters {
+		if _, ok := lus.relays[url]; ok {
+			continue
+		}
+
+		if !initial {
+			// events from before this point were already handled by the previous subscriptions
+			now := nostr.Now()
+			if flt.Since == nil || *flt.Since < now {
+				flt.Since = &now
+			}
+		}
+
+		subctx, cancel := context.WithCancel(ctx)
+		lus.relays[url] = &liveRelaySubscription{
+			authors: sortedAuthors(flt),
+			cancel:  cancel,
+		}
+
+		lus.wg.Add(1)
+		go lus.subscribe(subctx, url, flt)
+	}
+
+	return nil
+}
+
+func (lus *liveUserSubscription) subscribe(ctx context.Context, url string, filter nostr.Filter) {
+	defer lus.wg.Done()
+
+	for ie := range lus.sys.Pool.SubMany(ctx, []string{url}, nostr.Filters{filter}) {
+		lus.mu.Lock()
+		_, seen := lus.seen[ie.Event.ID]
+		lus.seen[ie.Event.ID] = ie.Event.CreatedAt
+		lus.mu.Unlock()
+		if seen {
+			continue
+		}
+
+		select {
+		case lus.events <- ie.Event:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// forget drops seen events created before the given time so we don't keep all ids forever.
+//
+// by the time this is called all subscriptions are long past their stored events and only get new ones,
+// which can't be older than that.
+func (lus *liveUserSubscription) forget(before nostr.Timestamp) {
+	lus.mu.Lock()
+	defer lus.mu.Unlock()
+
+	for id, createdAt := range lus.seen {
+		if createdAt < before {
+			delete(lus.seen, id)
+		}
+	}
+}
+
+// watchRelayLists listens for new relay lists from our authors so we can replan immediately when they change.
+func (lus *liveUserSubscription) watchRelayLists(ctx context.Context) {
+	now := nostr.Now()
+	for ie := range lus.sys.Pool.SubMany(ctx, lus.sys.filterBlockedRelays(lus.sys.RelayListRelays), nostr.Filters{
+		{
+			Kinds:   []int{nostr.KindRelayListMetadata},
+			Authors: lus.filter.Authors,
+			Since:   &now,
+		},
+	}) {
+		if rl, ok := lus.sys.RelayListCache.Get(ie.PubKey); ok && rl.Event != nil && rl.Event.CreatedAt >= ie.CreatedAt {
+			continue
+		}
+
+		// store the new list and make sure it's used next time we plan
+		lus.sys.StoreRelay.Publish(ctx, *ie.Event)
+		lus.sys.RelayListCache.Delete(ie.PubKey)
+		lus.sys.outboxShortTermCache.Delete(ie.PubKey)
+
+		select {
+		case lus.replan <- struct{}{}:
+		default:
+			// a replan is already scheduled
+		}
+	}
+}
+
+func sortedAuthors(filter nostr.Filter) []string {
+	authors := slices.Clone(filter.Authors)
+	slices.Sort(authors)
+	return authors
+}
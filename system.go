@@ -2,6 +2,9 @@ package sdk
 
 import (
 	"context"
+	"net/http"
+	"sync"
+	"time"
 
 	"github.com/fiatjaf/eventstore"
 	"github.com/fiatjaf/eventstore/slicestore"
@@ -11,12 +14,25 @@ import (
 	cache_memory "github.com/nbd-wtf/nostr-sdk/cache/memory"
 	"github.com/nbd-wtf/nostr-sdk/hints"
 	memory_hints "github.com/nbd-wtf/nostr-sdk/hints/memory"
+	"github.com/nbd-wtf/nostr-sdk/keyring"
 )
 
 type System struct {
-	RelayListCache   cache.Cache32[RelayList]
-	FollowListCache  cache.Cache32[FollowList]
-	MetadataCache    cache.Cache32[ProfileMetadata]
+	RelayListCache  cache.Cache32[RelayList]
+	FollowListCache cache.Cache32[FollowList]
+	MetadataCache   cache.Cache32[ProfileMetadata]
+
+	MuteListCache         cache.Cache32[MuteList]
+	PinnedNotesCache      cache.Cache32[PinnedNotesList]
+	BookmarkListCache     cache.Cache32[BookmarkList]
+	CommunityListCache    cache.Cache32[CommunityList]
+	PublicChatListCache   cache.Cache32[PublicChatList]
+	BlockedRelayListCache cache.Cache32[BlockedRelayList]
+	SearchRelayListCache  cache.Cache32[SearchRelayList]
+	InterestListCache     cache.Cache32[InterestList]
+	EmojiListCache        cache.Cache32[EmojiList]
+	NIP05Cache            cache.Cache32[NIP05Verification]
+
 	Hints            hints.HintsDB
 	Pool             *nostr.SimplePool
 	RelayListRelays  []string
@@ -25,11 +41,24 @@ type System struct {
 	FallbackRelays   []string
 	UserSearchRelays []string
 	NoteSearchRelays []string
+	UserSearchScorer UserSearchScorer
+	HTTPClient       *http.Client
 	Store            eventstore.Store
 
+	// when set, profiles and lists served from the cache or from the store are fetched again in the background
+	// if we haven't asked relays for them in this long, and OnRefresh is called when a newer version is found
+	SoftTTL   time.Duration
+	OnRefresh func(RefreshedValue)
+
 	StoreRelay nostr.RelayStore
 
+	acting       *actingUser
+	profileIndex *profileIndex
+	followGraphs *followGraphs
+	freshness    *freshness
+
 	replaceableLoaders   map[int]*dataloader.Loader[string, *nostr.Event]
+	addressableLoader    *dataloader.Loader[string, *nostr.Event]
 	outboxShortTermCache cache.Cache32[[]string]
 }
 
@@ -37,9 +66,21 @@ type SystemModifier func(sys *System)
 
 func NewSystem(mods ...SystemModifier) *System {
 	sys := &System{
-		RelayListCache:   cache_memory.New32[RelayList](1000),
-		FollowListCache:  cache_memory.New32[FollowList](1000),
-		MetadataCache:    cache_memory.New32[ProfileMetadata](1000),
+		RelayListCache:  cache_memory.New32[RelayList](1000),
+		FollowListCache: cache_memory.New32[FollowList](1000),
+		MetadataCache:   cache_memory.New32[ProfileMetadata](1000),
+
+		MuteListCache:         cache_memory.New32[MuteList](1000),
+		PinnedNotesCache:      cache_memory.New32[PinnedNotesList](1000),
+		BookmarkListCache:     cache_memory.New32[BookmarkList](1000),
+		CommunityListCache:    cache_memory.New32[CommunityList](1000),
+		PublicChatListCache:   cache_memory.New32[PublicChatList](1000),
+		BlockedRelayListCache: cache_memory.New32[BlockedRelayList](1000),
+		SearchRelayListCache:  cache_memory.New32[SearchRelayList](1000),
+		InterestListCache:     cache_memory.New32[InterestList](1000),
+		EmojiListCache:        cache_memory.New32[EmojiList](1000),
+		NIP05Cache:            cache_memory.New32[NIP05Verification](1000),
+
 		RelayListRelays:  []string{"wss://purplepag.es", "wss://user.kindpag.es", "wss://relay.nos.social"},
 		FollowListRelays: []string{"wss://purplepag.es", "wss://user.kindpag.es", "wss://relay.nos.social"},
 		MetadataRelays:   []string{"wss://purplepag.es", "wss://user.kindpag.es", "wss://relay.nos.social"},
@@ -65,10 +106,22 @@ func NewSystem(mods ...SystemModifier) *System {
 			"wss://relay.noswhere.com",
 		},
 		Hints: memory_hints.NewHintDB(),
+		HTTPClient: &http.Client{
+			Timeout: time.Second * 10,
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
 
 		outboxShortTermCache: cache_memory.New32[[]string](1000),
+		acting:               &actingUser{},
+		profileIndex:         newProfileIndex(),
+		followGraphs:         &followGraphs{graphs: make(map[string]*FollowGraph)},
+		freshness:            newFreshness(),
 	}
 
+	sys.UserSearchScorer = sys.DefaultUserSearchScore
+
 	sys.Pool = nostr.NewSimplePool(context.Background(),
 		nostr.WithEventMiddleware(sys.trackEventHints),
 		nostr.WithPenaltyBox(),
@@ -91,6 +144,122 @@ func NewSystem(mods ...SystemModifier) *System {
 
 func (sys *System) Close() {}
 
+// actingUser holds the logged-in user (if any) and the policies derived from their lists.
+type actingUser struct {
+	sync.RWMutex
+	pubkey          string
+	blockedRelays   []string
+	blockedRelaysAt nostr.Timestamp // created_at of the list the blocked relays came from
+	cipher          keyring.Cipher
+
+	// the private items of the acting user's lists, decrypted with the cipher above. these are only kept here,
+	// never in the list caches, so they are gone when the acting user or the cipher changes.
+	privateTags map[string]nostr.Tags // { [event id]: decrypted tags }
+}
+
+// SetActingUser sets the user on whose behalf this System is operating (i.e. the logged-in user) and loads
+// their preferences, like their blocked relays list, that will be applied to all queries and publishes from now on.
+// pass an empty pubkey to clear it.
+func (sys *System) SetActingUser(ctx context.Context, pubkey string) {
+	var blocked []string
+	var blockedAt nostr.Timestamp
+	if pubkey != "" {
+		bl := sys.FetchBlockedRelays(ctx, pubkey)
+		blocked = make([]string, len(bl.Items))
+		for i, r := range bl.Items {
+			blocked[i] = string(r)
+		}
+		if bl.Event != nil {
+			blockedAt = bl.Event.CreatedAt
+		}
+	}
+
+	sys.acting.Lock()
+	defer sys.acting.Unlock()
+	if sys.acting.pubkey != pubkey {
+		sys.acting.cipher = nil
+		sys.acting.privateTags = nil
+	}
+	sys.acting.pubkey = pubkey
+	sys.acting.blockedRelays = blocked
+	sys.acting.blockedRelaysAt = blockedAt
+}
+
+// actingUserListSaved must be called whenever a replaceable event is saved or published so the policies derived
+// from the acting user's lists are kept up-to-date with their latest versions.
+func (sys *System) actingUserListSaved(evt *nostr.Event) {
+	if evt.Kind != 10006 {
+		return
+	}
+
+	sys.acting.Lock()
+	defer sys.acting.Unlock()
+	if evt.PubKey != sys.acting.pubkey || evt.CreatedAt < sys.acting.blockedRelaysAt {
+		return
+	}
+
+	items := parseItemsFromEventTags(evt, parseRelayURL)
+	blocked := make([]string, len(items))
+	for i, r := range items {
+		blocked[i] = string(r)
+	}
+	sys.acting.blockedRelays = blocked
+	sys.acting.blockedRelaysAt = evt.CreatedAt
+}
+
+// SetActingUserCipher gives the System a way to decrypt the private items in the acting user's lists, which will
+// then be included in all the lists fetched for them. It must be called after SetActingUser.
+func (sys *System) SetActingUserCipher(cipher keyring.Cipher) {
+	sys.acting.Lock()
+	defer sys.acting.Unlock()
+	sys.acting.cipher = cipher
+	sys.acting.privateTags = nil
+}
+
+// actingUserPrivateTags returns the decrypted private tags of a list event if it belongs to the acting user and
+// we have their cipher. decrypted tags are kept in memory so we don't have to decrypt the same event again.
+func (sys *System) actingUserPrivateTags(ctx context.Context, evt *nostr.Event) (nostr.Tags, bool) {
+	sys.acting.RLock()
+	cipher := sys.acting.cipher
+	tags, ok := sys.acting.privateTags[evt.ID]
+	isActing := evt.PubKey != "" && evt.PubKey == sys.acting.pubkey
+	sys.acting.RUnlock()
+
+	if !isActing || cipher == nil {
+		return nil, false
+	}
+	if ok {
+		return tags, true
+	}
+
+	tags, err := decryptPrivateTags(ctx, cipher, evt)
+	if err != nil {
+		return nil, false
+	}
+	sys.rememberActingUserPrivateTags(evt, tags)
+	return tags, true
+}
+
+// rememberActingUserPrivateTags saves the decrypted private tags of a list event of the acting user in memory.
+func (sys *System) rememberActingUserPrivateTags(evt *nostr.Event, tags nostr.Tags) {
+	sys.acting.Lock()
+	defer sys.acting.Unlock()
+	if evt.PubKey != sys.acting.pubkey || sys.acting.cipher == nil {
+		return
+	}
+	if sys.acting.privateTags == nil {
+		sys.acting.privateTags = make(map[string]nostr.Tags)
+	}
+	sys.acting.privateTags[evt.ID] = tags
+}
+
+// ActingUser returns the pubkey set with SetActingUser, or an empty string.
+func (sys *System) ActingUser() string {
+	sys.acting.RLock()
+	defer sys.acting.RUnlock()
+	return sys.acting.pubkey
+}
+
 func WithHintsDB(hdb hints.HintsDB) SystemModifier {
 	return func(sys *System) {
 		sys.Hints = hdb
@@ -133,6 +302,28 @@ func WithNoteSearchRelays(list []string) SystemModifier {
 	}
 }
 
+func WithUserSearchScorer(scorer UserSearchScorer) SystemModifier {
+	return func(sys *System) {
+		sys.UserSearchScorer = scorer
+	}
+}
+
+// WithSoftTTL enables background refreshes of profiles and lists that haven't been checked on relays for
+// the given duration, calling onRefresh (which may be nil) when a newer version is found.
+func WithSoftTTL(ttl time.Duration, onRefresh func(RefreshedValue)) SystemModifier {
+	return func(sys *System) {
+		sys.SoftTTL = ttl
+		sys.OnRefresh = onRefresh
+	}
+}
+
+// WithHTTPClient sets the client used for HTTP requests, like NIP-05 verification.
+func WithHTTPClient(client *http.Client) SystemModifier {
+	return func(sys *System) {
+		sys.HTTPClient = client
+	}
+}
+
 func WithStore(store eventstore.Store) SystemModifier {
 	return func(sys *System) {
 		sys.Store = store
@@ -156,3 +347,63 @@ func WithMetadataCache(cache cache.Cache32[ProfileMetadata]) SystemModifier {
 		sys.MetadataCache = cache
 	}
 }
+
+func WithMuteListCache(cache cache.Cache32[MuteList]) SystemModifier {
+	return func(sys *System) {
+		sys.MuteListCache = cache
+	}
+}
+
+func WithPinnedNotesCache(cache cache.Cache32[PinnedNotesList]) SystemModifier {
+	return func(sys *System) {
+		sys.PinnedNotesCache = cache
+	}
+}
+
+func WithBookmarkListCache(cache cache.Cache32[BookmarkList]) SystemModifier {
+	return func(sys *System) {
+		sys.BookmarkListCache = cache
+	}
+}
+
+func WithCommunityListCache(cache cache.Cache32[CommunityList]) SystemModifier {
+	return func(sys *System) {
+		sys.CommunityListCache = cache
+	}
+}
+
+func WithPublicChatListCache(cache cache.Cache32[PublicChatList]) SystemModifier {
+	return func(sys *System) {
+		sys.PublicChatListCache = cache
+	}
+}
+
+func WithBlockedRelayListCache(cache cache.Cache32[BlockedRelayList]) SystemModifier {
+	return func(sys *System) {
+		sys.BlockedRelayListCache = cache
+	}
+}
+
+func WithSearchRelayListCache(cache cache.Cache32[SearchRelayList]) SystemModifier {
+	return func(sys *System) {
+		sys.SearchRelayListCache = cache
+	}
+}
+
+func WithInterestListCache(cache cache.Cache32[InterestList]) SystemModifier {
+	return func(sys *System) {
+		sys.InterestListCache = cache
+	}
+}
+
+func WithEmojiListCache(cache cache.Cache32[EmojiList]) SystemModifier {
+	return func(sys *System) {
+		sys.EmojiListCache = cache
+	}
+}
+
+func WithNIP05Cache(cache cache.Cache32[NIP05Verification]) SystemModifier {
+	return func(sys *System) {
+		sys.NIP05Cache = cache
+	}
+}
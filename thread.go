@@ -0,0 +1,214 @@
+package sdk
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/nbd-wtf/go-nostr"
+	"github.com/nbd-wtf/go-nostr/nip10"
+	"github.com/nbd-wtf/nostr-sdk/hints"
+)
+
+// Thread is a NIP-10 conversation arranged as a tree starting at its root.
+type Thread struct {
+	Root  *ThreadNode
+	Nodes map[string]*ThreadNode // { [id]: node }, includes the root and all placeholders
+}
+
+// ThreadNode is an event in a thread along with its direct replies, sorted from older to newer.
+//
+// when an event is referenced as a parent but couldn't be found the node is a placeholder with only the ID set
+// (Event will be nil) and it will be attached to the root of the thread.
+type ThreadNode struct {
+	ID      string
+	Event   *nostr.Event
+	Parent  *ThreadNode
+	Replies []*ThreadNode
+}
+
+// IsPlaceholder tells if this node stands for an event we couldn't find.
+func (tn *ThreadNode) IsPlaceholder() bool { return tn.Event == nil }
+
+// FetchThread finds the root of the thread the given event belongs to, then fetches all replies to that root from
+// the inbox and outbox relays of the root author and outbox relays of the other participants, and assembles them
+// into a tree. every event found is saved in the local store.
+func (sys *System) FetchThread(ctx context.Context, pointer nostr.EventPointer) (*Thread, error) {
+	evt, err := sys.fetchEvent(ctx, nostr.Filter{IDs: []string{pointer.ID}}, pointer.Author, pointer.Relays, hints.LastInNevent)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch event %s: %w", pointer.ID, err)
+	}
+
+	// find the root
+	root := evt
+	rootID := evt.ID
+	rootAuthor := evt.PubKey
+	if tag := nip10.GetThreadRoot(evt.Tags); tag != nil && nostr.IsValid32ByteHex((*tag)[1]) {
+		rootID = (*tag)[1]
+		rootAuthor = ""
+		hintRelays := make([]string, 0, 1)
+		if len(*tag) >= 3 && (*tag)[2] != "" {
+			hintRelays = append(hintRelays, (*tag)[2])
+		}
+		if len(*tag) >= 5 && nostr.IsValidPublicKey((*tag)[4]) {
+			rootAuthor = (*tag)[4]
+		}
+
+		root, _ = sys.fetchEvent(ctx, nostr.Filter{IDs: []string{rootID}}, rootAuthor, hintRelays, hints.LastInTag)
+		if root != nil {
+			rootAuthor = root.PubKey
+		}
+	}
+
+	// gather everybody involved so we know where to look for replies
+	participants := make([]string, 0, 12)
+	for _, e := range []*nostr.Event{root, evt} {
+		if e == nil {
+			continue
+		}
+		if !slices.Contains(participants, e.PubKey) {
+			participants = append(participants, e.PubKey)
+		}
+		for _, tag := range e.Tags {
+			if len(participants) >= 12 {
+				break
+			}
+			if len(tag) >= 2 && tag[0] == "p" && nostr.IsValidPublicKey(tag[1]) && !slices.Contains(participants, tag[1]) {
+				participants = append(participants, tag[1])
+			}
+		}
+	}
+
+	relays := sys.determineRelaysForThread(ctx, rootAuthor, participants)
+	events := sys.fetchReplies(ctx, rootID, relays)
+	if root != nil {
+		events = append(events, root)
+	}
+	if !slices.ContainsFunc(events, func(e *nostr.Event) bool { return e.ID == evt.ID }) {
+		events = append(events, evt)
+	}
+
+	return buildThread(rootID, events), nil
+}
+
+func (sys *System) determineRelaysForThread(ctx context.Context, rootAuthor string, participants []string) []string {
+	relays := make([]string, 0, 12)
+	mu := sync.Mutex{}
+	add := func(urls []string) {
+		mu.Lock()
+		defer mu.Unlock()
+		for _, url := range urls {
+			if !slices.Contains(relays, url) {
+				relays = append(relays, url)
+			}
+		}
+	}
+
+	wg := sync.WaitGroup{}
+	if rootAuthor != "" {
+		// replies are supposed to be sent to the root author's inbox
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			add(sys.FetchInboxRelays(ctx, rootAuthor, 3))
+		}()
+	}
+	wg.Add(len(participants))
+	for _, pubkey := range participants {
+		go func(pubkey string) {
+			defer wg.Done()
+			add(sys.FetchOutboxRelays(ctx, pubkey, 2))
+		}(pubkey)
+	}
+	wg.Wait()
+
+	return relays
+}
+
+// fetchReplies returns all the events that reference the given root, from the local store and from the given relays.
+func (sys *System) fetchReplies(ctx context.Context, rootID string, relays []string) []*nostr.Event {
+	filter := nostr.Filter{
+		Kinds: []int{nostr.KindTextNote},
+		Tags:  nostr.TagMap{"e": []string{rootID}},
+	}
+
+	events, _ := sys.StoreRelay.QuerySync(ctx, filter)
+
+	ctx, cancel := context.WithTimeout(ctx, time.Second*5)
+	defer cancel()
+
+	for ie := range sys.Pool.SubManyEose(ctx, relays, nostr.Filters{filter}) {
+		if slices.ContainsFunc(events, func(e *nostr.Event) bool { return e.ID == ie.Event.ID }) {
+			continue
+		}
+		if ok, _ := ie.Event.CheckSignature(); !ok {
+			continue
+		}
+		events = append(events, ie.Event)
+		sys.StoreRelay.Publish(ctx, *ie.Event)
+	}
+
+	return events
+}
+
+// buildThread arranges the given events into a tree according to their NIP-10 "e" tags.
+func buildThread(rootID string, events []*nostr.Event) *Thread {
+	thread := &Thread{
+		Nodes: make(map[string]*ThreadNode, len(events)+1),
+	}
+
+	for _, evt := range events {
+		thread.Nodes[evt.ID] = &ThreadNode{ID: evt.ID, Event: evt}
+	}
+
+	root, ok := thread.Nodes[rootID]
+	if !ok {
+		root = &ThreadNode{ID: rootID}
+		thread.Nodes[rootID] = root
+	}
+	thread.Root = root
+
+	for _, evt := range events {
+		if evt.ID == rootID {
+			continue
+		}
+		node := thread.Nodes[evt.ID]
+
+		parent := root
+		if tag := nip10.GetImmediateReply(evt.Tags); tag != nil && (*tag)[0] == "e" && (*tag)[1] != evt.ID {
+			parentID := (*tag)[1]
+			if p, ok := thread.Nodes[parentID]; ok {
+				parent = p
+			} else if nostr.IsValid32ByteHex(parentID) {
+				// we don't have this one, so add a placeholder hanging from the root
+				p = &ThreadNode{ID: parentID, Parent: root}
+				root.Replies = append(root.Replies, p)
+				thread.Nodes[parentID] = p
+				parent = p
+			}
+		}
+
+		node.Parent = parent
+		parent.Replies = append(parent.Replies, node)
+	}
+
+	for _, node := range thread.Nodes {
+		slices.SortFunc(node.Replies, func(a, b *ThreadNode) int {
+			// placeholders come first
+			switch {
+			case a.Event == nil && b.Event == nil:
+				return 0
+			case a.Event == nil:
+				return -1
+			case b.Event == nil:
+				return 1
+			}
+			return cmp.Compare(a.Event.CreatedAt, b.Event.CreatedAt)
+		})
+	}
+
+	return thread
+}
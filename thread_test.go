@@ -0,0 +1,52 @@
+package sdk
+
+import (
+	"testing"
+
+	"github.com/nbd-wtf/go-nostr"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildThread(t *testing.T) {
+	const root = "0000000000000000000000000000000000000000000000000000000000000001"
+	const a = "0000000000000000000000000000000000000000000000000000000000000002"
+	const b = "0000000000000000000000000000000000000000000000000000000000000003"
+	const missing = "0000000000000000000000000000000000000000000000000000000000000004"
+	const c = "0000000000000000000000000000000000000000000000000000000000000005"
+	const d = "0000000000000000000000000000000000000000000000000000000000000006"
+
+	events := []*nostr.Event{
+		// marked reply to the root
+		{ID: a, CreatedAt: 20, Tags: nostr.Tags{{"e", root, "", "root"}}},
+		// marked reply to a
+		{ID: b, CreatedAt: 30, Tags: nostr.Tags{{"e", root, "", "root"}, {"e", a, "", "reply"}}},
+		// reply to an event we don't have
+		{ID: c, CreatedAt: 40, Tags: nostr.Tags{{"e", root, "", "root"}, {"e", missing, "", "reply"}}},
+		// positional reply to a, with no markers
+		{ID: d, CreatedAt: 10, Tags: nostr.Tags{{"e", root}, {"e", a}}},
+		{ID: root, CreatedAt: 1},
+	}
+
+	thread := buildThread(root, events)
+	require.Equal(t, root, thread.Root.ID)
+	require.False(t, thread.Root.IsPlaceholder())
+	require.Len(t, thread.Nodes, 6)
+
+	require.Len(t, thread.Root.Replies, 2)
+	require.True(t, thread.Root.Replies[0].IsPlaceholder())
+	require.Equal(t, missing, thread.Root.Replies[0].ID)
+	require.Equal(t, c, thread.Root.Replies[0].Replies[0].ID)
+	require.Equal(t, a, thread.Root.Replies[1].ID)
+
+	nodeA := thread.Nodes[a]
+	require.Len(t, nodeA.Replies, 2)
+	require.Equal(t, d, nodeA.Replies[0].ID)
+	require.Equal(t, b, nodeA.Replies[1].ID)
+	require.Equal(t, nodeA, thread.Nodes[b].Parent)
+
+	// when we don't have the root it becomes a placeholder
+	thread = buildThread(root, events[0:2])
+	require.True(t, thread.Root.IsPlaceholder())
+	require.Equal(t, a, thread.Root.Replies[0].ID)
+	require.Equal(t, b, thread.Root.Replies[0].Replies[0].ID)
+}
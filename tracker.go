@@ -1,6 +1,7 @@
 package sdk
 
 import (
+	"context"
 	"net/url"
 
 	"github.com/nbd-wtf/go-nostr"
@@ -12,6 +13,8 @@ func (sys *System) trackEventHints(ie nostr.IncomingEvent) {
 		return
 	}
 
+	sys.replaceIfNewer(ie.Event)
+
 	switch ie.Kind {
 	case nostr.KindRelayListMetadata:
 		for _, tag := range ie.Tags {
@@ -84,3 +87,80 @@ func (sys *System) trackEventHints(ie nostr.IncomingEvent) {
 		}
 	}
 }
+
+// replaceIfNewer checks if an event seen in any subscription is a newer version of a profile, follow list,
+// relay list or blocked relays list we already know about and, if so, replaces it in the store and invalidates
+// the caches so the new version is used from now on.
+func (sys *System) replaceIfNewer(evt *nostr.Event) {
+	if evt.Kind != 0 && evt.Kind != 3 && evt.Kind != 10002 && evt.Kind != 10006 {
+		return
+	}
+
+	var cachedAt nostr.Timestamp
+	var cached bool
+	switch evt.Kind {
+	case 0:
+		var pm ProfileMetadata
+		if pm, cached = sys.MetadataCache.Get(evt.PubKey); cached && pm.Event != nil {
+			cachedAt = pm.Event.CreatedAt
+		}
+	case 3:
+		var fl FollowList
+		if fl, cached = sys.FollowListCache.Get(evt.PubKey); cached && fl.Event != nil {
+			cachedAt = fl.Event.CreatedAt
+		}
+	case 10002:
+		var rl RelayList
+		if rl, cached = sys.RelayListCache.Get(evt.PubKey); cached && rl.Event != nil {
+			cachedAt = rl.Event.CreatedAt
+		}
+	case 10006:
+		var bl BlockedRelayList
+		if bl, cached = sys.BlockedRelayListCache.Get(evt.PubKey); cached && bl.Event != nil {
+			cachedAt = bl.Event.CreatedAt
+		}
+	}
+
+	if cached {
+		if cachedAt < evt.CreatedAt {
+			// (the store won't replace an even newer version it may have)
+			sys.replaceStored(evt)
+		}
+		return
+	}
+
+	// this runs for every profile and list coming from relays, so we don't hold them up with the store query
+	go func() {
+		stored, _ := sys.StoreRelay.QuerySync(context.Background(),
+			nostr.Filter{Kinds: []int{evt.Kind}, Authors: []string{evt.PubKey}})
+		if len(stored) == 0 {
+			// we don't know anything about this, so we don't care
+			return
+		}
+		if stored[0].CreatedAt < evt.CreatedAt {
+			sys.replaceStored(evt)
+		}
+	}()
+}
+
+// replaceStored saves a newer version of a replaceable event in the store and invalidates the caches.
+func (sys *System) replaceStored(evt *nostr.Event) {
+	sys.StoreRelay.Publish(context.Background(), *evt)
+	sys.actingUserListSaved(evt)
+
+	// the next fetch will read the new version from the store
+	switch evt.Kind {
+	case 0:
+		sys.MetadataCache.Delete(evt.PubKey)
+		if pm, err := ParseMetadata(evt); err == nil {
+			sys.indexProfile(pm)
+		}
+	case 3:
+		sys.FollowListCache.Delete(evt.PubKey)
+	case 10002:
+		sys.RelayListCache.Delete(evt.PubKey)
+		sys.outboxShortTermCache.Delete(evt.PubKey)
+	case 10006:
+		sys.BlockedRelayListCache.Delete(evt.PubKey)
+	}
+}
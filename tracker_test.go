@@ -0,0 +1,109 @@
+package sdk
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/fiatjaf/eventstore/badger"
+	"github.com/nbd-wtf/go-nostr"
+	cache_memory "github.com/nbd-wtf/nostr-sdk/cache/memory"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReplaceIfNewer(t *testing.T) {
+	ctx := context.Background()
+	sys := NewSystem()
+	wait := func() { sys.MetadataCache.(*cache_memory.RistrettoCache[ProfileMetadata]).Cache.Wait() }
+	relay := &nostr.Relay{URL: "wss://relay.com"}
+
+	pubkey, _ := nostr.GetPublicKey(nostr.GeneratePrivateKey())
+	profile := func(name string, createdAt nostr.Timestamp) *nostr.Event {
+		evt := &nostr.Event{Kind: 0, PubKey: pubkey, CreatedAt: createdAt, Tags: nostr.Tags{}, Content: `{"name":"` + name + `"}`}
+		evt.ID = evt.GetID()
+		return evt
+	}
+
+	now := nostr.Now()
+	sys.Store.SaveEvent(ctx, profile("old", now-100))
+	require.Equal(t, "old", sys.FetchProfileMetadata(ctx, pubkey).Name)
+	wait()
+
+	// an older version is ignored
+	sys.trackEventHints(nostr.IncomingEvent{Event: profile("older", now-200), Relay: relay})
+	wait()
+	require.Equal(t, "old", sys.FetchProfileMetadata(ctx, pubkey).Name)
+
+	// a newer version replaces what we have
+	sys.trackEventHints(nostr.IncomingEvent{Event: profile("new", now), Relay: relay})
+	wait()
+	require.Equal(t, "new", sys.FetchProfileMetadata(ctx, pubkey).Name)
+	stored, _ := sys.StoreRelay.QuerySync(ctx, nostr.Filter{Kinds: []int{0}, Authors: []string{pubkey}})
+	require.Len(t, stored, 1)
+	require.Equal(t, "new", sys.SearchLocalProfiles("new", 1)[0].Name)
+
+	// profiles we don't know about are not stored
+	other, _ := nostr.GetPublicKey(nostr.GeneratePrivateKey())
+	evt := &nostr.Event{Kind: 0, PubKey: other, CreatedAt: now, Content: `{}`}
+	sys.trackEventHints(nostr.IncomingEvent{Event: evt, Relay: relay})
+	stored, _ = sys.StoreRelay.QuerySync(ctx, nostr.Filter{Kinds: []int{0}, Authors: []string{other}})
+	require.Empty(t, stored)
+}
+
+func TestReplaceIfNewerInBackground(t *testing.T) {
+	ctx := context.Background()
+	store := &badger.BadgerBackend{Path: t.TempDir()}
+	require.NoError(t, store.Init())
+	defer store.Close()
+	sys := NewSystem(WithStore(store))
+	relay := &nostr.Relay{URL: "wss://relay.com"}
+
+	pubkey, _ := nostr.GetPublicKey(nostr.GeneratePrivateKey())
+	now := nostr.Now()
+
+	// things that aren't cached are checked against the store without blocking
+	follows := &nostr.Event{Kind: 3, PubKey: pubkey, CreatedAt: now - 100, Tags: nostr.Tags{}}
+	follows.ID = follows.GetID()
+	sys.Store.SaveEvent(ctx, follows)
+	newer := &nostr.Event{Kind: 3, PubKey: pubkey, CreatedAt: now, Tags: nostr.Tags{{"p", pubkey}}}
+	newer.ID = newer.GetID()
+	sys.trackEventHints(nostr.IncomingEvent{Event: newer, Relay: relay})
+
+	require.Eventually(t, func() bool {
+		stored, _ := sys.StoreRelay.QuerySync(ctx, nostr.Filter{Kinds: []int{3}, Authors: []string{pubkey}})
+		return len(stored) == 1 && stored[0].ID == newer.ID
+	}, time.Second*2, time.Millisecond*10)
+}
+
+func TestActingUserBlockedRelaysFollowNewerLists(t *testing.T) {
+	ctx := context.Background()
+	sys := NewSystem()
+	relay := &nostr.Relay{URL: "wss://relay.com"}
+
+	pubkey, _ := nostr.GetPublicKey(nostr.GeneratePrivateKey())
+	blocked := func(url string, createdAt nostr.Timestamp) *nostr.Event {
+		evt := &nostr.Event{Kind: 10006, PubKey: pubkey, CreatedAt: createdAt, Tags: nostr.Tags{{"relay", url}}}
+		evt.ID = evt.GetID()
+		return evt
+	}
+
+	now := nostr.Now()
+	sys.Store.SaveEvent(ctx, blocked("wss://old.com", now-100))
+	sys.SetActingUser(ctx, pubkey)
+	require.True(t, sys.IsRelayBlocked("wss://old.com"))
+	sys.BlockedRelayListCache.(*cache_memory.RistrettoCache[BlockedRelayList]).Cache.Wait()
+
+	// a newer list replaces the policy
+	sys.trackEventHints(nostr.IncomingEvent{Event: blocked("wss://new.com", now), Relay: relay})
+	require.False(t, sys.IsRelayBlocked("wss://old.com"))
+	require.True(t, sys.IsRelayBlocked("wss://new.com"))
+
+	// urls that aren't normalized are blocked too
+	require.True(t, sys.IsRelayBlocked("wss://NEW.com/"))
+	require.Equal(t, []string{"wss://other.com"},
+		sys.filterBlockedRelays([]string{"wss://new.com/", "wss://New.com", "wss://other.com"}))
+
+	// an older one doesn't
+	sys.actingUserListSaved(blocked("wss://older.com", now-200))
+	require.True(t, sys.IsRelayBlocked("wss://new.com"))
+}
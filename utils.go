@@ -3,6 +3,8 @@ package sdk
 import (
 	"sync"
 	"time"
+
+	"github.com/nbd-wtf/go-nostr"
 )
 
 var (
@@ -11,9 +13,12 @@ var (
 )
 
 func DoThisNotMoreThanOnceAnHour(key string) (doItNow bool) {
+	_dtnmtoahLock.Lock()
+	defer _dtnmtoahLock.Unlock()
+
 	if _dtnmtoah == nil {
+		_dtnmtoah = make(map[string]time.Time)
 		go func() {
-			_dtnmtoah = make(map[string]time.Time)
 			for {
 				time.Sleep(time.Minute * 10)
 				_dtnmtoahLock.Lock()
@@ -28,9 +33,6 @@ func DoThisNotMoreThanOnceAnHour(key string) (doItNow bool) {
 		}()
 	}
 
-	_dtnmtoahLock.Lock()
-	defer _dtnmtoahLock.Unlock()
-
 	_, exists := _dtnmtoah[key]
 	return !exists
 }
@@ -41,3 +43,13 @@ func pickNext(list []string) string {
 	serial++
 	return list[serial%len(list)]
 }
+
+// nextTimestamp returns the current time or, if that isn't enough to replace the given event (i.e. when
+// it was created in the future or in this same second), a timestamp just after it.
+func nextTimestamp(prev *nostr.Event) nostr.Timestamp {
+	now := nostr.Now()
+	if prev != nil && prev.CreatedAt >= now {
+		return prev.CreatedAt + 1
+	}
+	return now
+}
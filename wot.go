@@ -0,0 +1,150 @@
+package sdk
+
+import (
+	"context"
+	"math"
+	"sync"
+)
+
+// FollowGraph is a web-of-trust built by crawling the follow lists of a root user, then the follow lists of
+// the people they follow and so on, up to a number of hops.
+type FollowGraph struct {
+	Root string
+	Hops int
+
+	mu        sync.RWMutex
+	distances map[string]int      // { [pubkey]: hops away from the root }
+	follows   map[string][]string // { [pubkey]: pubkeys they follow }, only for crawled pubkeys
+	followers map[string][]string // { [pubkey]: crawled pubkeys that follow them }
+}
+
+// CrawlFollowGraph builds a FollowGraph starting at root and going hops levels deep. Follow lists are fetched
+// through the batched replaceable loader and saved in the local store, so subsequent crawls are cheaper.
+//
+// the number of lists fetched grows very fast with each hop, 2 is a good value for most purposes.
+// the resulting graph is kept in the System and can be retrieved later with FollowGraph(root).
+func (sys *System) CrawlFollowGraph(ctx context.Context, root string, hops int) *FollowGraph {
+	return sys.buildFollowGraph(ctx, root, hops, false)
+}
+
+// LoadFollowGraph rebuilds a FollowGraph from the follow lists saved in the local store by a previous call to
+// CrawlFollowGraph (possibly before a restart), without querying any relay. Like CrawlFollowGraph, the graph
+// is kept in the System and can be retrieved later with FollowGraph(root).
+func (sys *System) LoadFollowGraph(ctx context.Context, root string, hops int) *FollowGraph {
+	return sys.buildFollowGraph(ctx, root, hops, true)
+}
+
+func (sys *System) buildFollowGraph(ctx context.Context, root string, hops int, localOnly bool) *FollowGraph {
+	g := &FollowGraph{
+		Root:      root,
+		Hops:      hops,
+		distances: map[string]int{root: 0},
+		follows:   make(map[string][]string, 500),
+		followers: make(map[string][]string, 500),
+	}
+
+	frontier := []string{root}
+	for d := 0; d < hops && len(frontier) > 0 && ctx.Err() == nil; d++ {
+		next := make([]string, 0, len(frontier)*50)
+		sem := make(chan struct{}, 120)
+
+		wg := sync.WaitGroup{}
+		wg.Add(len(frontier))
+		for _, pubkey := range frontier {
+			sem <- struct{}{}
+			go func(pubkey string) {
+				defer wg.Done()
+				defer func() { <-sem }()
+
+				fl, _ := fetchGenericList[Follow](sys, ctx, pubkey, 3, parseFollow, sys.FollowListCache, localOnly)
+				follows := make([]string, 0, len(fl.Items))
+				for _, f := range fl.Items {
+					follows = append(follows, f.Pubkey)
+				}
+
+				g.mu.Lock()
+				defer g.mu.Unlock()
+				g.follows[pubkey] = follows
+				for _, target := range follows {
+					g.followers[target] = append(g.followers[target], pubkey)
+					if _, ok := g.distances[target]; !ok {
+						g.distances[target] = d + 1
+						next = append(next, target)
+					}
+				}
+			}(pubkey)
+		}
+		wg.Wait()
+
+		frontier = next
+	}
+
+	sys.followGraphs.Lock()
+	sys.followGraphs.graphs[root] = g
+	sys.followGraphs.Unlock()
+
+	return g
+}
+
+// FollowGraph returns the last graph built from the given root with CrawlFollowGraph or LoadFollowGraph, or nil.
+// graphs are only kept in memory, so after a restart LoadFollowGraph must be called again.
+func (sys *System) FollowGraph(root string) *FollowGraph {
+	sys.followGraphs.Lock()
+	defer sys.followGraphs.Unlock()
+	return sys.followGraphs.graphs[root]
+}
+
+type followGraphs struct {
+	sync.Mutex
+	graphs map[string]*FollowGraph // { [root]: graph }
+}
+
+// Distance returns how many hops away from the root the given pubkey is, or false if it's not in the graph.
+func (g *FollowGraph) Distance(pubkey string) (int, bool) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	d, ok := g.distances[pubkey]
+	return d, ok
+}
+
+// FollowerCount returns how many of the crawled pubkeys follow the given pubkey.
+func (g *FollowGraph) FollowerCount(pubkey string) int {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return len(g.followers[pubkey])
+}
+
+// Follows returns the pubkeys followed by the given pubkey, if it was crawled.
+func (g *FollowGraph) Follows(pubkey string) []string {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.follows[pubkey]
+}
+
+// Size returns the number of pubkeys in the graph, crawled or not.
+func (g *FollowGraph) Size() int {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return len(g.distances)
+}
+
+// TrustScore returns a number between 0 and 1 telling how much the given pubkey can be trusted from the
+// point of view of the root: 1 is the root itself, 0 is someone nobody in the graph follows.
+//
+// each follower vouches for the pubkey with a weight that halves at every hop away from the root, and these
+// are combined such that more followers always mean more trust, without ever reaching 1.
+func (g *FollowGraph) TrustScore(pubkey string) float64 {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	if pubkey == g.Root {
+		return 1
+	}
+
+	distrust := 1.0
+	for _, follower := range g.followers[pubkey] {
+		weight := 0.9 * math.Pow(0.5, float64(g.distances[follower]))
+		distrust *= 1 - weight
+	}
+	return 1 - distrust
+}
@@ -0,0 +1,66 @@
+package sdk
+
+import (
+	"context"
+	"testing"
+
+	"github.com/nbd-wtf/go-nostr"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCrawlFollowGraph(t *testing.T) {
+	ctx := context.Background()
+	sys := NewSystem()
+
+	pk := func() string {
+		pubkey, _ := nostr.GetPublicKey(nostr.GeneratePrivateKey())
+		return pubkey
+	}
+	root, alice, bob, carol, dave := pk(), pk(), pk(), pk(), pk()
+
+	// everybody who is less than 2 hops away must have a follow list in the store so we don't hit the network
+	for author, follows := range map[string][]string{
+		root:  {alice, bob},
+		alice: {bob, carol},
+		bob:   {carol, root},
+	} {
+		tags := make(nostr.Tags, len(follows))
+		for i, f := range follows {
+			tags[i] = nostr.Tag{"p", f}
+		}
+		evt := &nostr.Event{Kind: 3, PubKey: author, CreatedAt: nostr.Now(), Tags: tags}
+		evt.ID = evt.GetID()
+		sys.Store.SaveEvent(ctx, evt)
+	}
+
+	g := sys.CrawlFollowGraph(ctx, root, 2)
+	require.Same(t, g, sys.FollowGraph(root))
+	require.Equal(t, 4, g.Size())
+
+	for pubkey, expected := range map[string]int{root: 0, alice: 1, bob: 1, carol: 2} {
+		d, ok := g.Distance(pubkey)
+		require.True(t, ok)
+		require.Equal(t, expected, d)
+	}
+	_, ok := g.Distance(dave)
+	require.False(t, ok)
+
+	require.Equal(t, 2, g.FollowerCount(bob))
+	require.Equal(t, 2, g.FollowerCount(carol))
+	require.Equal(t, 1, g.FollowerCount(alice))
+	require.ElementsMatch(t, []string{bob, carol}, g.Follows(alice))
+
+	require.Equal(t, 1.0, g.TrustScore(root))
+	require.Equal(t, 0.0, g.TrustScore(dave))
+	require.Greater(t, g.TrustScore(bob), g.TrustScore(alice))
+	require.Greater(t, g.TrustScore(alice), g.TrustScore(carol))
+	require.Less(t, g.TrustScore(bob), 1.0)
+
+	// after a restart the same graph can be rebuilt from the store alone
+	sys.followGraphs.graphs = make(map[string]*FollowGraph)
+	require.Nil(t, sys.FollowGraph(root))
+	loaded := sys.LoadFollowGraph(ctx, root, 2)
+	require.Same(t, loaded, sys.FollowGraph(root))
+	require.Equal(t, g.Size(), loaded.Size())
+	require.Equal(t, g.TrustScore(carol), loaded.TrustScore(carol))
+}